@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"strings"
+
+	"vector-database/config"
+)
+
+// Analyzer turns raw text into the terms used by encoders and lexical scoring.
+type Analyzer interface {
+	Analyze(text string) []string
+}
+
+// TokenFilter transforms a token stream, e.g. dropping stop words or stemming.
+type TokenFilter func(tokens []string) []string
+
+type chain struct {
+	tokenize func(text string) []string
+	filters  []TokenFilter
+}
+
+// New builds the analysis chain described by the config. The zero config
+// is the legacy lowercase whitespace split, so existing embeddings stay
+// valid until an operator opts into another chain.
+func New(cfg config.Analyzer) (Analyzer, error) {
+	if err := config.ValidateAnalyzer(cfg); err != nil {
+		return nil, err
+	}
+	c := &chain{tokenize: strings.Fields}
+
+	if cfg.Tokenizer == config.TokenizerUnicode {
+		size := cfg.NGramSize
+		if size <= 0 {
+			size = defaultNGramSize
+		}
+		c.tokenize = func(text string) []string {
+			return segment(text, size)
+		}
+	}
+
+	c.filters = append(c.filters, lowercase)
+	if cfg.StopWords == config.LanguageEnglish {
+		c.filters = append(c.filters, stopWordFilter(englishStopWords))
+	}
+	if cfg.Stemmer == config.LanguageEnglish {
+		c.filters = append(c.filters, stemFilter)
+	}
+	return c, nil
+}
+
+// Analyze runs the tokenizer followed by every configured filter.
+func (c *chain) Analyze(text string) []string {
+	tokens := c.tokenize(text)
+	for _, filter := range c.filters {
+		if len(tokens) == 0 {
+			break
+		}
+		tokens = filter(tokens)
+	}
+	return tokens
+}
+
+func lowercase(tokens []string) []string {
+	for i, token := range tokens {
+		tokens[i] = strings.ToLower(token)
+	}
+	return tokens
+}
+
+func stopWordFilter(words map[string]struct{}) TokenFilter {
+	return func(tokens []string) []string {
+		kept := tokens[:0]
+		for _, token := range tokens {
+			if _, ok := words[token]; ok {
+				continue
+			}
+			kept = append(kept, token)
+		}
+		return kept
+	}
+}
+
+func stemFilter(tokens []string) []string {
+	for i, token := range tokens {
+		tokens[i] = stem(token)
+	}
+	return tokens
+}
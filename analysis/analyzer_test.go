@@ -0,0 +1,82 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"vector-database/config"
+)
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"running":        "run",
+		"agreed":         "agre",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"generalization": "gener",
+		"hopeful":        "hope",
+		"adjustable":     "adjust",
+		"controll":       "control",
+		"go":             "go",
+		"x86":            "x86",
+		"café":           "café",
+	}
+	for word, want := range cases {
+		if got := stem(word); got != want {
+			t.Errorf("stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestNewDefaultsToLegacySplit(t *testing.T) {
+	analyzer, err := New(config.Analyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := analyzer.Analyze("The Search. engine")
+	want := []string{"the", "search.", "engine"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Analyze = %q, want %q", got, want)
+	}
+}
+
+func TestNewChain(t *testing.T) {
+	analyzer, err := New(config.Analyzer{
+		Tokenizer: config.TokenizerUnicode,
+		StopWords: config.LanguageEnglish,
+		Stemmer:   config.LanguageEnglish,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := analyzer.Analyze("The engines are Running, e.g. quickly!")
+	want := []string{"engin", "run", "e.g", "quickli"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Analyze = %q, want %q", got, want)
+	}
+}
+
+func TestSegmentNGrams(t *testing.T) {
+	got := segment("東京都 abc", 2)
+	want := []string{"東京", "京都", "abc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("segment = %q, want %q", got, want)
+	}
+}
+
+func TestNewRejectsUnknownSettings(t *testing.T) {
+	for _, cfg := range []config.Analyzer{
+		{Tokenizer: "regex"},
+		{StopWords: "french"},
+		{Stemmer: "german"},
+		{NGramSize: -1},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package analysis
+
+import "strings"
+
+// stem reduces an English word to its Porter stem. Tokens that are not plain
+// ASCII lowercase letters (numbers, other scripts, joined tokens) are
+// returned unchanged.
+func stem(word string) string {
+	if len(word) <= 2 || !isASCIILower(word) {
+		return word
+	}
+	s := &stemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+func isASCIILower(word string) bool {
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+type stemmer struct {
+	b []byte
+}
+
+func (s *stemmer) isConsonant(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.isConsonant(i-1)
+	}
+	return true
+}
+
+// measure counts the VC sequences in b[:end].
+func (s *stemmer) measure(end int) int {
+	n, i := 0, 0
+	for i < end && s.isConsonant(i) {
+		i++
+	}
+	for i < end {
+		for i < end && !s.isConsonant(i) {
+			i++
+		}
+		if i >= end {
+			break
+		}
+		for i < end && s.isConsonant(i) {
+			i++
+		}
+		n++
+	}
+	return n
+}
+
+func (s *stemmer) hasVowel(end int) bool {
+	for i := 0; i < end; i++ {
+		if !s.isConsonant(i) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *stemmer) endsDoubleConsonant(end int) bool {
+	return end >= 2 && s.b[end-1] == s.b[end-2] && s.isConsonant(end-1)
+}
+
+// endsCVC reports whether b[:end] ends consonant-vowel-consonant where the
+// last consonant is not w, x or y.
+func (s *stemmer) endsCVC(end int) bool {
+	if end < 3 || !s.isConsonant(end-1) || s.isConsonant(end-2) || !s.isConsonant(end-3) {
+		return false
+	}
+	switch s.b[end-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func (s *stemmer) hasSuffix(suffix string) bool {
+	return strings.HasSuffix(string(s.b), suffix)
+}
+
+func (s *stemmer) replace(suffix, replacement string) {
+	s.b = append(s.b[:len(s.b)-len(suffix)], replacement...)
+}
+
+// replaceIfMeasure swaps suffix for replacement when the remaining stem has a
+// measure greater than min. It reports whether the suffix matched at all.
+func (s *stemmer) replaceIfMeasure(suffix, replacement string, min int) bool {
+	if !s.hasSuffix(suffix) {
+		return false
+	}
+	if s.measure(len(s.b)-len(suffix)) > min {
+		s.replace(suffix, replacement)
+	}
+	return true
+}
+
+func (s *stemmer) step1a() {
+	switch {
+	case s.hasSuffix("sses"):
+		s.replace("sses", "ss")
+	case s.hasSuffix("ies"):
+		s.replace("ies", "i")
+	case s.hasSuffix("ss"):
+	case s.hasSuffix("s"):
+		s.replace("s", "")
+	}
+}
+
+func (s *stemmer) step1b() {
+	if s.hasSuffix("eed") {
+		if s.measure(len(s.b)-3) > 0 {
+			s.replace("eed", "ee")
+		}
+		return
+	}
+
+	var removed bool
+	for _, suffix := range []string{"ed", "ing"} {
+		if s.hasSuffix(suffix) && s.hasVowel(len(s.b)-len(suffix)) {
+			s.replace(suffix, "")
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return
+	}
+
+	switch {
+	case s.hasSuffix("at"), s.hasSuffix("bl"), s.hasSuffix("iz"):
+		s.b = append(s.b, 'e')
+	case s.endsDoubleConsonant(len(s.b)):
+		switch s.b[len(s.b)-1] {
+		case 'l', 's', 'z':
+		default:
+			s.b = s.b[:len(s.b)-1]
+		}
+	case s.measure(len(s.b)) == 1 && s.endsCVC(len(s.b)):
+		s.b = append(s.b, 'e')
+	}
+}
+
+func (s *stemmer) step1c() {
+	if s.hasSuffix("y") && s.hasVowel(len(s.b)-1) {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+var step2Suffixes = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+func (s *stemmer) step2() {
+	for _, pair := range step2Suffixes {
+		if s.replaceIfMeasure(pair[0], pair[1], 0) {
+			return
+		}
+	}
+}
+
+var step3Suffixes = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+func (s *stemmer) step3() {
+	for _, pair := range step3Suffixes {
+		if s.replaceIfMeasure(pair[0], pair[1], 0) {
+			return
+		}
+	}
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func (s *stemmer) step4() {
+	// Longest suffix wins, e.g. "ement" before "ment" before "ent".
+	best := ""
+	for _, suffix := range step4Suffixes {
+		if len(suffix) > len(best) && s.hasSuffix(suffix) {
+			best = suffix
+		}
+	}
+	if best == "" {
+		return
+	}
+
+	end := len(s.b) - len(best)
+	if best == "ion" && (end == 0 || (s.b[end-1] != 's' && s.b[end-1] != 't')) {
+		return
+	}
+	if s.measure(end) > 1 {
+		s.b = s.b[:end]
+	}
+}
+
+func (s *stemmer) step5() {
+	if s.hasSuffix("e") {
+		end := len(s.b) - 1
+		m := s.measure(end)
+		if m > 1 || (m == 1 && !s.endsCVC(end)) {
+			s.b = s.b[:end]
+		}
+	}
+	if s.hasSuffix("ll") && s.measure(len(s.b)) > 1 {
+		s.b = s.b[:len(s.b)-1]
+	}
+}
@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"unicode"
+)
+
+const defaultNGramSize = 2
+
+// unsegmentedScripts are written without spaces between words, so runs of
+// these characters are indexed as overlapping character n-grams instead.
+var unsegmentedScripts = []*unicode.RangeTable{
+	unicode.Thai,
+	unicode.Lao,
+	unicode.Khmer,
+	unicode.Myanmar,
+	unicode.Han,
+	unicode.Hiragana,
+	unicode.Katakana,
+}
+
+type runeClass int
+
+const (
+	classSeparator runeClass = iota
+	classWord
+	classUnsegmented
+	classJoiner
+)
+
+// segment splits text into word tokens. Punctuation and symbols separate
+// words unless they join two word characters ("e.g", "3.14", "pix:3f"), and
+// runs of unsegmented scripts are emitted as character n-grams of the given
+// size.
+func segment(text string, ngramSize int) []string {
+	runes := []rune(text)
+	tokens := make([]string, 0, len(runes)/4+1)
+
+	start := -1
+	currentClass := classSeparator
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		switch currentClass {
+		case classWord:
+			tokens = append(tokens, string(runes[start:end]))
+		case classUnsegmented:
+			tokens = appendNGrams(tokens, runes[start:end], ngramSize)
+		}
+		start = -1
+		currentClass = classSeparator
+	}
+
+	for i, r := range runes {
+		class := classify(r)
+		if class == classJoiner {
+			if currentClass == classWord && i+1 < len(runes) && classify(runes[i+1]) == classWord {
+				continue
+			}
+			class = classSeparator
+		}
+
+		if class != currentClass {
+			flush(i)
+			if class != classSeparator {
+				start = i
+				currentClass = class
+			}
+		}
+	}
+	flush(len(runes))
+
+	return tokens
+}
+
+func classify(r rune) runeClass {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsMark(r):
+		if unicode.In(r, unsegmentedScripts...) {
+			return classUnsegmented
+		}
+		return classWord
+	case unicode.IsDigit(r) || unicode.IsNumber(r):
+		return classWord
+	case r == '\'' || r == '’' || r == '.' || r == ':' || r == '_':
+		return classJoiner
+	default:
+		return classSeparator
+	}
+}
+
+// appendNGrams emits n-grams over character clusters so combining marks
+// (Thai tone marks and vowels above/below) stay attached to their base.
+func appendNGrams(tokens []string, run []rune, size int) []string {
+	var clusters []int
+	for i, r := range run {
+		if i == 0 || !unicode.IsMark(r) {
+			clusters = append(clusters, i)
+		}
+	}
+	clusters = append(clusters, len(run))
+
+	count := len(clusters) - 1
+	if count <= size {
+		return append(tokens, string(run))
+	}
+	for i := 0; i+size <= count; i++ {
+		tokens = append(tokens, string(run[clusters[i]:clusters[i+size]]))
+	}
+	return tokens
+}
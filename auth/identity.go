@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"vector-database/config"
+)
+
+// Identity is the caller an API key belongs to. Requests act for its
+// Tenant.
+type Identity struct {
+	KeyID  string
+	Name   string
+	Scopes []string
+	Tenant string
+}
+
+// Allows reports whether the identity holds scope. The admin scope grants
+// every scope, and an empty scope only requires authentication.
+func (i Identity) Allows(scope string) bool {
+	return scope == "" || slices.Contains(i.Scopes, scope) || slices.Contains(i.Scopes, config.ScopeAdmin)
+}
+
+type identityKey struct{}
+
+// NewContext returns a copy of ctx carrying the identity.
+func NewContext(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity the request was authenticated as.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/config"
+	"vector-database/db/apikey"
+	"vector-database/model"
+	"vector-database/tenant"
+)
+
+// keyPrefix marks secrets issued by this service so they are easy to spot
+// in logs and secret scanners.
+const keyPrefix = "vdb_"
+
+var (
+	// ErrUnauthenticated is returned for missing, unknown or revoked keys.
+	ErrUnauthenticated = errors.New("invalid api key")
+	// ErrInvalidArgument is returned for malformed key management requests.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrNotFound is returned when revoking a key that does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned when creating a key for another tenant.
+	ErrForbidden = errors.New("forbidden")
+	// ErrNoKeyStore is returned by key management without mongo.collection.apiKeys.
+	ErrNoKeyStore = errors.New("api keys can only be managed with mongo.collection.apiKeys configured")
+)
+
+// Keyring authenticates API keys against the configured bootstrap keys and
+// the key store, and manages the stored keys of the caller's tenant.
+type Keyring interface {
+	Authenticate(ctx context.Context, secret string) (Identity, error)
+	Create(ctx context.Context, name string, scopes []string, tenantID string) (model.APIKey, string, error)
+	List(ctx context.Context) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type keyringImp struct {
+	static map[string]Identity
+	store  apikey.Store
+}
+
+// NewKeyring combines the keys from configuration with store, which may be
+// nil when keys are only configured statically.
+func NewKeyring(cfg config.Auth, store apikey.Store) Keyring {
+	static := make(map[string]Identity, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		static[strings.ToLower(key.Hash)] = Identity{
+			KeyID:  "config:" + key.Name,
+			Name:   key.Name,
+			Scopes: key.Scopes,
+			Tenant: orDefaultTenant(key.Tenant),
+		}
+	}
+	return &keyringImp{static: static, store: store}
+}
+
+// HashKey returns the hex SHA-256 digest stored in place of a secret.
+func HashKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (k *keyringImp) Authenticate(ctx context.Context, secret string) (Identity, error) {
+	if secret == "" {
+		return Identity{}, ErrUnauthenticated
+	}
+	hash := HashKey(secret)
+	if identity, ok := k.static[hash]; ok {
+		return identity, nil
+	}
+	if k.store == nil {
+		return Identity{}, ErrUnauthenticated
+	}
+
+	key, err := k.store.FindByHash(ctx, hash)
+	if errors.Is(err, apikey.ErrNotFound) {
+		return Identity{}, ErrUnauthenticated
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{KeyID: key.ID.Hex(), Name: key.Name, Scopes: key.Scopes, Tenant: orDefaultTenant(key.Tenant)}, nil
+}
+
+// Create stores a new key for the creator's tenant and returns it with its
+// secret, which is not recoverable afterwards. tenantID may only name the
+// creator's own tenant; keys for other tenants are bootstrapped in config.
+func (k *keyringImp) Create(ctx context.Context, name string, scopes []string, tenantID string) (model.APIKey, string, error) {
+	if k.store == nil {
+		return model.APIKey{}, "", ErrNoKeyStore
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return model.APIKey{}, "", fmt.Errorf("%w: name is required", ErrInvalidArgument)
+	}
+	if err := config.ValidateScopes(scopes); err != nil {
+		return model.APIKey{}, "", fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if requested := strings.TrimSpace(tenantID); requested != "" && requested != tenant.FromContext(ctx) {
+		return model.APIKey{}, "", fmt.Errorf("%w: keys can only be created for tenant %q", ErrForbidden, tenant.FromContext(ctx))
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return model.APIKey{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+	secret := keyPrefix + hex.EncodeToString(raw)
+
+	key := model.APIKey{
+		Name:      name,
+		Hash:      HashKey(secret),
+		Scopes:    scopes,
+		Tenant:    tenant.FromContext(ctx),
+		CreatedAt: time.Now().UTC(),
+	}
+	if creator, ok := FromContext(ctx); ok {
+		key.CreatedBy = creator.Name
+	}
+
+	key, err := k.store.Insert(ctx, key)
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// List returns the stored keys of the caller's tenant.
+func (k *keyringImp) List(ctx context.Context) ([]model.APIKey, error) {
+	if k.store == nil {
+		return nil, ErrNoKeyStore
+	}
+	return k.store.List(ctx)
+}
+
+// Revoke deletes a stored key of the caller's tenant. Keys of other
+// tenants are reported as not found.
+func (k *keyringImp) Revoke(ctx context.Context, id string) error {
+	if k.store == nil {
+		return ErrNoKeyStore
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("%w: invalid key id %q", ErrInvalidArgument, id)
+	}
+	err = k.store.Delete(ctx, objectID)
+	if errors.Is(err, apikey.ErrNotFound) {
+		return fmt.Errorf("%w: api key %s", ErrNotFound, id)
+	}
+	return err
+}
+
+func orDefaultTenant(id string) string {
+	if id == "" {
+		return tenant.Default
+	}
+	return id
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/config"
+	"vector-database/db/apikey"
+	"vector-database/model"
+	"vector-database/tenant"
+)
+
+// memoryKeys scopes List and Delete to the context's tenant, as the Mongo
+// store does.
+type memoryKeys struct {
+	keys []model.APIKey
+}
+
+func (m *memoryKeys) FindByHash(_ context.Context, hash string) (model.APIKey, error) {
+	for _, key := range m.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return model.APIKey{}, apikey.ErrNotFound
+}
+
+func (m *memoryKeys) Insert(_ context.Context, key model.APIKey) (model.APIKey, error) {
+	key.ID = primitive.NewObjectID()
+	m.keys = append(m.keys, key)
+	return key, nil
+}
+
+func (m *memoryKeys) List(ctx context.Context) ([]model.APIKey, error) {
+	var owned []model.APIKey
+	for _, key := range m.keys {
+		if key.Tenant == tenant.FromContext(ctx) {
+			owned = append(owned, key)
+		}
+	}
+	return owned, nil
+}
+
+func (m *memoryKeys) Delete(ctx context.Context, id primitive.ObjectID) error {
+	for i, key := range m.keys {
+		if key.ID == id && key.Tenant == tenant.FromContext(ctx) {
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			return nil
+		}
+	}
+	return apikey.ErrNotFound
+}
+
+func TestKeyringScopesKeysToTheCallersTenant(t *testing.T) {
+	keyring := NewKeyring(config.Auth{}, &memoryKeys{})
+	acme := tenant.NewContext(context.Background(), "acme")
+	other := tenant.NewContext(context.Background(), "other")
+	scopes := []string{config.ScopeSearchRead}
+
+	if _, _, err := keyring.Create(acme, "escalate", scopes, "other"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("creating a key for another tenant: %v, want ErrForbidden", err)
+	}
+	key, secret, err := keyring.Create(acme, "reader", scopes, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Tenant != "acme" {
+		t.Errorf("key tenant = %q, want acme", key.Tenant)
+	}
+	if identity, err := keyring.Authenticate(other, secret); err != nil || identity.Tenant != "acme" {
+		t.Errorf("Authenticate() = %+v, %v, want the acme identity", identity, err)
+	}
+
+	if keys, _ := keyring.List(other); len(keys) != 0 {
+		t.Errorf("other tenant lists %v", keys)
+	}
+	if err := keyring.Revoke(other, key.ID.Hex()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoking another tenant's key: %v, want ErrNotFound", err)
+	}
+	if err := keyring.Revoke(acme, key.ID.Hex()); err != nil {
+		t.Errorf("revoking an own key: %v", err)
+	}
+}
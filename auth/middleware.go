@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"vector-database/httpinfo"
+	"vector-database/logging"
+	"vector-database/tenant"
+)
+
+// Middleware authenticates every request with the key from the
+// Authorization: Bearer or X-API-Key header, checks it holds the scope
+// scopeFor returns, and stores the Identity and its tenant in the request
+// context.
+func Middleware(keyring Keyring, scopeFor func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := keyring.Authenticate(r.Context(), requestKey(r))
+			if errors.Is(err, ErrUnauthenticated) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				writeError(w, r, http.StatusUnauthorized, "a valid api key is required")
+				return
+			}
+			if err != nil {
+				logging.FromContext(r.Context()).Error("authenticate request", "error", err)
+				writeError(w, r, http.StatusServiceUnavailable, "authentication is unavailable")
+				return
+			}
+
+			if scope := scopeFor(r); !identity.Allows(scope) {
+				writeError(w, r, http.StatusForbidden, "api key lacks the "+scope+" scope")
+				return
+			}
+
+			ctx := tenant.NewContext(NewContext(r.Context(), identity), identity.Tenant)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func requestKey(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	httpinfo.WriteError(w, r, status, httpinfo.APIError{Message: msg})
+}
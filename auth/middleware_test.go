@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"vector-database/config"
+	"vector-database/httpinfo"
+)
+
+// testKeyring knows one configured key per scope, named after the scope.
+func testKeyring() Keyring {
+	var keys []config.APIKey
+	for _, scope := range config.Scopes {
+		keys = append(keys, config.APIKey{Name: scope, Hash: HashKey("secret-" + scope), Scopes: []string{scope}})
+	}
+	return NewKeyring(config.Auth{Keys: keys}, nil)
+}
+
+// serve runs a request through Middleware with ScopeFor, sending the key
+// of scope when it is not empty, and reports the status and whether the
+// request reached the handler.
+func serve(method, path, scope string) (int, bool) {
+	served := false
+	handler := Middleware(testKeyring(), httpinfo.ScopeFor)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		served = true
+	}))
+
+	req := httptest.NewRequest(method, path, nil)
+	if scope != "" {
+		req.Header.Set("Authorization", "Bearer secret-"+scope)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code, served
+}
+
+// concretePath fills the path parameters of an endpoint pattern.
+func concretePath(pattern string) string {
+	return strings.ReplaceAll(pattern, "{id}", "67009e42b3f629343e58802a")
+}
+
+func TestMiddlewareEnforcesEndpointScopes(t *testing.T) {
+	for _, ep := range httpinfo.Endpoints {
+		if ep.Scope == "" {
+			continue
+		}
+		path := concretePath(ep.Path)
+		for _, scope := range append([]string{""}, config.Scopes...) {
+			want := http.StatusForbidden
+			switch {
+			case scope == "":
+				want = http.StatusUnauthorized
+			case scope == ep.Scope, scope == config.ScopeAdmin:
+				want = http.StatusOK
+			}
+			status, served := serve(ep.Method, path, scope)
+			if status != want || served != (want == http.StatusOK) {
+				t.Errorf("%s %s with key %q: status %d, served %v, want %d", ep.Method, path, scope, status, served, want)
+			}
+		}
+	}
+}
+
+func TestMiddlewareScopeEdgeCases(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		scope  string
+		want   int
+	}{
+		{"HEAD needs the GET scope", http.MethodHead, httpinfo.StatusEndpoint.Path, config.ScopeSearchRead, http.StatusForbidden},
+		{"HEAD with the GET scope", http.MethodHead, httpinfo.StatusEndpoint.Path, config.ScopeAdmin, http.StatusOK},
+		{"HEAD on a search route", http.MethodHead, httpinfo.GetMessageEndpoint.Path, config.ScopeMessagesWrite, http.StatusForbidden},
+		{"unmatched path", http.MethodGet, "/api/nowhere", config.ScopeSearchRead, http.StatusOK},
+		{"unmatched method", http.MethodPut, httpinfo.StatusEndpoint.Path, config.ScopeSearchRead, http.StatusOK},
+		{"unmatched path without a key", http.MethodGet, "/api/nowhere", "", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		status, served := serve(tc.method, tc.path, tc.scope)
+		if status != tc.want || served != (tc.want == http.StatusOK) {
+			t.Errorf("%s: %s %s with key %q: status %d, served %v, want %d", tc.name, tc.method, tc.path, tc.scope, status, served, tc.want)
+		}
+	}
+}
+
+func TestRequestKeyHeaders(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		value  string
+		want   string
+	}{
+		{"bearer", "Authorization", "Bearer abc", "abc"},
+		{"bearer any case", "Authorization", "bearer  abc ", "abc"},
+		{"api key header", "X-API-Key", " abc ", "abc"},
+		{"other scheme", "Authorization", "Basic abc", ""},
+		{"none", "", "", ""},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		if got := requestKey(req); got != tc.want {
+			t.Errorf("%s: requestKey() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateRerankBoostRules(t *testing.T) {
+	cases := []struct {
+		name string
+		rule BoostRule
+		ok   bool
+	}{
+		{"boost", BoostRule{Field: "topic", Equals: "demo", Boost: 1.2}, true},
+		{"missing boost", BoostRule{Field: "topic", Equals: "demo"}, false},
+		{"negative boost", BoostRule{Field: "topic", Equals: "demo", Boost: -1}, false},
+	}
+	for _, tc := range cases {
+		cfg := Rerank{Stages: []string{RerankMetadataStage}, Metadata: RerankMetadata{Rules: []BoostRule{tc.rule}}}
+		if err := validateRerank(cfg); (err == nil) != tc.ok {
+			t.Errorf("%s: validateRerank() = %v, want ok=%v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestValidateRejectsBinaryQuantizationForMemoryStore(t *testing.T) {
+	cases := []struct {
+		store        string
+		quantization string
+		ok           bool
+	}{
+		{StoreMemory, QuantizationNone, true},
+		{StoreMemory, QuantizationScalar, true},
+		{StoreMemory, QuantizationBinary, false},
+		{StoreMongo, QuantizationBinary, true},
+	}
+	for _, tc := range cases {
+		cfg := Configs{
+			MongoDB: MongoDB{URI: "mongodb://localhost", EmbeddingDimension: 8, Quantization: tc.quantization},
+			Store:   Store{Type: tc.store},
+		}
+		if err := validate(cfg); (err == nil) != tc.ok {
+			t.Errorf("%s store with %s quantization: validate() = %v, want ok=%v", tc.store, tc.quantization, err, tc.ok)
+		}
+	}
+}
+
+func TestTracingSampleRatioTellsUnsetFromZero(t *testing.T) {
+	cases := []struct {
+		yaml string
+		want *float64
+	}{
+		{"exporter: stdout", nil},
+		{"sampleRatio: 0", new(float64)},
+	}
+	for _, tc := range cases {
+		var cfg Tracing
+		if err := yaml.Unmarshal([]byte(tc.yaml), &cfg); err != nil {
+			t.Fatal(err)
+		}
+		if (cfg.SampleRatio == nil) != (tc.want == nil) || (cfg.SampleRatio != nil && *cfg.SampleRatio != *tc.want) {
+			t.Errorf("%q: sampleRatio = %v, want %v", tc.yaml, cfg.SampleRatio, tc.want)
+		}
+	}
+
+	bad := 1.5
+	if err := validateTracing(Tracing{SampleRatio: &bad}); err == nil {
+		t.Error("accepted a sample ratio above 1")
+	}
+}
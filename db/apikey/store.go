@@ -0,0 +1,101 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"vector-database/model"
+	"vector-database/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotFound is returned when no key has the requested id or hash.
+var ErrNotFound = errors.New("api key not found")
+
+// Store persists hashed API keys. List and Delete only see the keys of
+// the context's tenant; FindByHash authenticates across all of them.
+type Store interface {
+	FindByHash(ctx context.Context, hash string) (model.APIKey, error)
+	Insert(ctx context.Context, key model.APIKey) (model.APIKey, error)
+	List(ctx context.Context) ([]model.APIKey, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type mongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewStore wires the Mongo collection into an API key Store.
+func NewStore(collection *mongo.Collection) Store {
+	return &mongoStore{collection: collection}
+}
+
+// EnsureIndexes assigns keys created before tenants existed to
+// tenant.Default and makes key hashes unique so lookups hit at most one key.
+func EnsureIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.UpdateMany(ctx,
+		bson.D{{Key: "tenant", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "tenant", Value: tenant.Default}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("backfill api key tenants: %w", err)
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("create api key hash index: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoStore) FindByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	var key model.APIKey
+	err := m.collection.FindOne(ctx, bson.D{{Key: "hash", Value: hash}}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return model.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("find api key: %w", err)
+	}
+	return key, nil
+}
+
+func (m *mongoStore) Insert(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	key.ID = primitive.NewObjectID()
+	if _, err := m.collection.InsertOne(ctx, key); err != nil {
+		return model.APIKey{}, fmt.Errorf("insert api key: %w", err)
+	}
+	return key, nil
+}
+
+func (m *mongoStore) List(ctx context.Context) ([]model.APIKey, error) {
+	filter := bson.D{{Key: "tenant", Value: tenant.FromContext(ctx)}}
+	cursor, err := m.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	keys := []model.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("decode api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (m *mongoStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := m.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: id}, {Key: "tenant", Value: tenant.FromContext(ctx)}})
+	if err != nil {
+		return fmt.Errorf("delete api key: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
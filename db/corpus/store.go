@@ -0,0 +1,111 @@
+package corpus
+
+import (
+	"context"
+	"fmt"
+
+	"vector-database/model"
+	"vector-database/tenant"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// documentCountTerm keys the total document counter of a tenant. Analyzers
+// never emit an empty term, so it cannot collide with a real term.
+const documentCountTerm = ""
+
+// Store persists corpus term statistics per tenant. AddDocument updates
+// the statistics of the context's tenant.
+type Store interface {
+	Load(ctx context.Context) (map[string]model.CorpusStats, error)
+	AddDocument(ctx context.Context, terms []string) error
+}
+
+type mongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewStore wires the Mongo collection into a corpus Store implementation.
+func NewStore(collection *mongo.Collection) Store {
+	return &mongoStore{collection: collection}
+}
+
+// EnsureIndexes assigns statistics recorded before tenants existed, which
+// are keyed by the term alone, to tenant.Default and makes (tenant, term)
+// unique.
+func EnsureIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.UpdateMany(ctx,
+		bson.D{{Key: "tenant", Value: bson.D{{Key: "$exists", Value: false}}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.D{
+			{Key: "tenant", Value: tenant.Default},
+			{Key: "term", Value: "$_id"},
+		}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("backfill corpus tenants: %w", err)
+	}
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "term", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("create corpus term index: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoStore) Load(ctx context.Context) (map[string]model.CorpusStats, error) {
+	cursor, err := m.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("find corpus stats: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	stats := map[string]model.CorpusStats{}
+	for cursor.Next(ctx) {
+		var entry struct {
+			Tenant string `bson:"tenant"`
+			Term   string `bson:"term"`
+			Count  int64  `bson:"df"`
+		}
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("decode corpus stat: %w", err)
+		}
+		owned, ok := stats[entry.Tenant]
+		if !ok {
+			owned = model.CorpusStats{DocumentFrequency: map[string]int64{}}
+		}
+		if entry.Term == documentCountTerm {
+			owned.Documents = entry.Count
+		} else {
+			owned.DocumentFrequency[entry.Term] = entry.Count
+		}
+		stats[entry.Tenant] = owned
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("iterate corpus stats: %w", err)
+	}
+	return stats, nil
+}
+
+// AddDocument increments the document counter and the frequency of every
+// distinct term in a single unordered bulk write.
+func (m *mongoStore) AddDocument(ctx context.Context, terms []string) error {
+	owner := tenant.FromContext(ctx)
+	writes := make([]mongo.WriteModel, 0, len(terms)+1)
+	for _, term := range append([]string{documentCountTerm}, terms...) {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.D{{Key: "tenant", Value: owner}, {Key: "term", Value: term}}).
+			SetUpdate(bson.D{{Key: "$inc", Value: bson.D{{Key: "df", Value: 1}}}}).
+			SetUpsert(true))
+	}
+
+	if _, err := m.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("update corpus stats: %w", err)
+	}
+	return nil
+}
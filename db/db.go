@@ -5,41 +5,108 @@ import (
 	"fmt"
 
 	"vector-database/config"
+	"vector-database/db/apikey"
+	"vector-database/db/corpus"
 	"vector-database/db/document"
+	"vector-database/db/embeddingcache"
+	"vector-database/db/quota"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Database struct {
-	client    *mongo.Client
-	Documents document.Store
+	client         *mongo.Client
+	Documents      document.Store
+	Corpus         corpus.Store
+	EmbeddingCache embeddingcache.Store
+	APIKeys        apikey.Store
+	Quotas         quota.Store
 }
 
-func New(ctx context.Context, cfg config.MongoDB) (*Database, error) {
-	clientOpts := options.Client().ApplyURI(cfg.URI)
-	client, err := mongo.Connect(ctx, clientOpts)
+// New opens the configured stores. It connects to Mongo only when
+// cfg.UsesMongo, so the memory store can run without a deployment.
+func New(ctx context.Context, cfg config.Configs) (*Database, error) {
+	database := &Database{}
+	if cfg.Store.Type == config.StoreMemory {
+		documents, err := document.NewMemoryStore(cfg.MongoDB, cfg.Store)
+		if err != nil {
+			return nil, fmt.Errorf("init memory store: %w", err)
+		}
+		database.Documents = documents
+	}
+	if !cfg.UsesMongo() {
+		return database, nil
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoDB.URI))
 	if err != nil {
 		return nil, fmt.Errorf("connect mongo: %w", err)
 	}
-
 	if err := client.Ping(ctx, nil); err != nil {
 		return nil, fmt.Errorf("ping mongo: %w", err)
 	}
+	database.client = client
 
-	db := client.Database(cfg.Database)
-	docCollection := db.Collection(cfg.Collection.Document)
-	if err := document.EnsureIndexes(ctx, docCollection, cfg); err != nil {
-		return nil, err
+	db := client.Database(cfg.MongoDB.Database)
+	collections := cfg.MongoDB.Collection
+	if database.Documents == nil {
+		docCollection := db.Collection(collections.Document)
+		if err := document.EnsureIndexes(ctx, docCollection, cfg.MongoDB); err != nil {
+			return nil, err
+		}
+		database.Documents = document.NewStore(docCollection, cfg.MongoDB)
 	}
 
-	return &Database{
-		client:    client,
-		Documents: document.NewStore(docCollection, cfg),
-	}, nil
+	if collections.Corpus != "" {
+		corpusCollection := db.Collection(collections.Corpus)
+		if err := corpus.EnsureIndexes(ctx, corpusCollection); err != nil {
+			return nil, err
+		}
+		database.Corpus = corpus.NewStore(corpusCollection)
+	}
+	if collections.EmbeddingCache != "" {
+		database.EmbeddingCache = embeddingcache.NewStore(db.Collection(collections.EmbeddingCache))
+	}
+	if collections.APIKeys != "" {
+		keyCollection := db.Collection(collections.APIKeys)
+		if err := apikey.EnsureIndexes(ctx, keyCollection); err != nil {
+			return nil, err
+		}
+		database.APIKeys = apikey.NewStore(keyCollection)
+	}
+	if collections.Quotas != "" {
+		quotaCollection := db.Collection(collections.Quotas)
+		if err := quota.EnsureIndexes(ctx, quotaCollection); err != nil {
+			return nil, err
+		}
+		database.Quotas = quota.NewStore(quotaCollection)
+	}
+	return database, nil
 }
 
-// Close releases the Mongo client resources.
+// Connected reports whether the database holds a Mongo client.
+func (d *Database) Connected() bool {
+	return d.client != nil
+}
+
+// Ping checks the Mongo deployment is reachable.
+func (d *Database) Ping(ctx context.Context) error {
+	return d.client.Ping(ctx, nil)
+}
+
+// Close flushes stores that hold state in process and releases the Mongo
+// client resources.
 func (d *Database) Close(ctx context.Context) error {
-	return d.client.Disconnect(ctx)
+	var closeErr error
+	if closer, ok := d.Documents.(interface{ Close() error }); ok {
+		closeErr = closer.Close()
+	}
+	if d.client == nil {
+		return closeErr
+	}
+	if err := d.client.Disconnect(ctx); err != nil {
+		return err
+	}
+	return closeErr
 }
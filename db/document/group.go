@@ -0,0 +1,151 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+
+	"vector-database/model"
+	"vector-database/tracing"
+)
+
+// Grouper is implemented by stores that group search results themselves
+// rather than returning the whole candidate set to the service.
+type Grouper interface {
+	GroupSearch(ctx context.Context, query model.VectorQuery, field string, groups, perGroup int) (model.GroupedResults, error)
+}
+
+// GroupSearch runs the vector search and buckets its candidates in the same
+// aggregation: a $facet computes both the top groups with their best
+// documents and the counts of every value over the candidate set. Groups
+// and facets are ordered as model.GroupDocuments orders them. Grouped
+// searches do not page.
+func (m *mongoStore) GroupSearch(ctx context.Context, query model.VectorQuery, field string, groups, perGroup int) (_ model.GroupedResults, err error) {
+	ctx, span := m.startSpan(ctx, "GroupSearch")
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+	span.SetAttributes(
+		attribute.Int("vector.limit", query.FetchLimit()),
+		attribute.String("group.field", field),
+	)
+
+	if err := query.Validate(m.cfg.EmbeddingDimension); err != nil {
+		return model.GroupedResults{}, err
+	}
+	if query.Offset > 0 || query.After != nil {
+		return model.GroupedResults{}, errors.New("grouped searches cannot be paged")
+	}
+
+	vectorStage := bson.D{
+		{Key: "index", Value: m.cfg.VectorIndex},
+		{Key: "path", Value: "embedding"},
+		{Key: "queryVector", Value: float32ToFloat64(query.QueryVector)},
+		{Key: "numCandidates", Value: query.Candidates()},
+		{Key: "limit", Value: query.FetchLimit()},
+		{Key: "filter", Value: tenantFilter(ctx)},
+	}
+
+	groupKey := "$" + field
+	byValue := bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: groupKey},
+		{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		{Key: "best", Value: bson.D{{Key: "$first", Value: "$score"}}},
+		{Key: "bestId", Value: bson.D{{Key: "$first", Value: "$_id"}}},
+		{Key: "docs", Value: bson.D{{Key: "$push", Value: "$$ROOT"}}},
+	}}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$vectorSearch", Value: vectorStage}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "content", Value: 1},
+			{Key: "metadata", Value: 1},
+			{Key: "score", Value: bson.D{{Key: "$meta", Value: "vectorSearchScore"}}},
+		}}},
+	}
+	if query.MinScore > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{
+			{Key: "score", Value: bson.D{{Key: "$gte", Value: query.MinScore}}},
+		}}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}}},
+		bson.D{{Key: "$facet", Value: bson.D{
+			{Key: "groups", Value: bson.A{
+				byValue,
+				bson.D{{Key: "$sort", Value: bson.D{{Key: "best", Value: -1}, {Key: "bestId", Value: 1}}}},
+				bson.D{{Key: "$limit", Value: groups}},
+				bson.D{{Key: "$project", Value: bson.D{
+					{Key: "count", Value: 1},
+					{Key: "docs", Value: bson.D{{Key: "$slice", Value: bson.A{"$docs", perGroup}}}},
+				}}},
+			}},
+			{Key: "facets", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: groupKey},
+					{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+					{Key: "best", Value: bson.D{{Key: "$first", Value: "$score"}}},
+					{Key: "bestId", Value: bson.D{{Key: "$first", Value: "$_id"}}},
+				}}},
+				bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "best", Value: -1}, {Key: "bestId", Value: 1}}}},
+			}},
+		}}},
+	)
+
+	start := time.Now()
+	defer aggregateDuration.ObserveSince(start, "group_search")
+
+	cursor, err := m.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return model.GroupedResults{}, fmt.Errorf("grouped vector search aggregate: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var out []struct {
+		Groups []struct {
+			Value interface{} `bson:"_id"`
+			Count int         `bson:"count"`
+			Docs  []struct {
+				ID       primitive.ObjectID     `bson:"_id"`
+				Content  string                 `bson:"content"`
+				Metadata map[string]interface{} `bson:"metadata"`
+				Score    float64                `bson:"score"`
+			} `bson:"docs"`
+		} `bson:"groups"`
+		Facets []struct {
+			Value interface{} `bson:"_id"`
+			Count int         `bson:"count"`
+		} `bson:"facets"`
+	}
+	if err := cursor.All(ctx, &out); err != nil {
+		return model.GroupedResults{}, fmt.Errorf("decode grouped vector search result: %w", err)
+	}
+
+	var results model.GroupedResults
+	if len(out) == 0 {
+		return results, nil
+	}
+	for _, g := range out[0].Groups {
+		group := model.Group{Value: g.Value, Count: g.Count}
+		for _, doc := range g.Docs {
+			group.Documents = append(group.Documents, model.Document{
+				ID:       doc.ID,
+				Content:  doc.Content,
+				Metadata: doc.Metadata,
+				Score:    doc.Score,
+			})
+		}
+		results.Groups = append(results.Groups, group)
+	}
+	for _, f := range out[0].Facets {
+		results.Facets = append(results.Facets, model.Facet{Value: f.Value, Count: f.Count})
+	}
+	return results, nil
+}
@@ -0,0 +1,226 @@
+package document
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pqCodes is the number of centroids per sub-vector codebook, so every
+// sub-vector code fits in one byte.
+const pqCodes = 256
+
+// ivfpqCodebooks is the trained part of the index and is what gets persisted;
+// the inverted lists are rebuilt from the stored vectors.
+type ivfpqCodebooks struct {
+	Dimension  int
+	SubVectors int
+	// Coarse holds one centroid per inverted list.
+	Coarse [][]float32
+	// Codebooks holds, per sub-vector, the centroids of the residuals.
+	Codebooks [][][]float32
+}
+
+// ivfpqIndex is an inverted file index whose entries are product-quantized
+// residuals relative to their list centroid.
+type ivfpqIndex struct {
+	codebooks ivfpqCodebooks
+	subDim    int
+	lists     [][]ivfEntry
+	where     map[primitive.ObjectID]int
+	size      int
+}
+
+type ivfEntry struct {
+	id   primitive.ObjectID
+	code []byte
+}
+
+type ivfCandidate struct {
+	id       primitive.ObjectID
+	distance float32
+}
+
+// trainIVFPQ learns the coarse quantizer and the residual codebooks.
+func trainIVFPQ(vectors [][]float32, lists, subVectors, iterations int) (ivfpqCodebooks, error) {
+	if len(vectors) == 0 {
+		return ivfpqCodebooks{}, errors.New("no vectors to train on")
+	}
+	dim := len(vectors[0])
+	if subVectors <= 0 || dim%subVectors != 0 {
+		return ivfpqCodebooks{}, fmt.Errorf("dimension %d is not divisible into %d sub-vectors", dim, subVectors)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	coarse := kmeans(vectors, lists, iterations, rng)
+
+	residuals := make([][]float32, len(vectors))
+	for i, v := range vectors {
+		residuals[i] = residual(v, coarse[nearestCentroid(coarse, v)])
+	}
+
+	subDim := dim / subVectors
+	codebooks := make([][][]float32, subVectors)
+	for m := range codebooks {
+		parts := make([][]float32, len(residuals))
+		for i, r := range residuals {
+			parts[i] = r[m*subDim : (m+1)*subDim]
+		}
+		codebooks[m] = kmeans(parts, pqCodes, iterations, rng)
+	}
+
+	return ivfpqCodebooks{
+		Dimension:  dim,
+		SubVectors: subVectors,
+		Coarse:     coarse,
+		Codebooks:  codebooks,
+	}, nil
+}
+
+func newIVFPQIndex(codebooks ivfpqCodebooks) *ivfpqIndex {
+	return &ivfpqIndex{
+		codebooks: codebooks,
+		subDim:    codebooks.Dimension / codebooks.SubVectors,
+		lists:     make([][]ivfEntry, len(codebooks.Coarse)),
+		where:     map[primitive.ObjectID]int{},
+	}
+}
+
+func (ix *ivfpqIndex) add(id primitive.ObjectID, vector []float32) {
+	list := nearestCentroid(ix.codebooks.Coarse, vector)
+	r := residual(vector, ix.codebooks.Coarse[list])
+
+	code := make([]byte, ix.codebooks.SubVectors)
+	for m, codebook := range ix.codebooks.Codebooks {
+		code[m] = byte(nearestCentroid(codebook, r[m*ix.subDim:(m+1)*ix.subDim]))
+	}
+	ix.insert(list, ivfEntry{id: id, code: code})
+}
+
+func (ix *ivfpqIndex) insert(list int, entry ivfEntry) {
+	ix.lists[list] = append(ix.lists[list], entry)
+	ix.where[entry.id] = list
+	ix.size++
+}
+
+func (ix *ivfpqIndex) remove(id primitive.ObjectID) {
+	list, ok := ix.where[id]
+	if !ok {
+		return
+	}
+	entries := ix.lists[list]
+	for i, entry := range entries {
+		if entry.id == id {
+			entries[i] = entries[len(entries)-1]
+			ix.lists[list] = entries[:len(entries)-1]
+			break
+		}
+	}
+	delete(ix.where, id)
+	ix.size--
+}
+
+// probes maps a candidate budget to the number of inverted lists to scan,
+// assuming roughly even list sizes.
+func (ix *ivfpqIndex) probes(candidates int) int {
+	lists := len(ix.lists)
+	if ix.size == 0 || lists == 0 {
+		return lists
+	}
+	avg := float64(ix.size) / float64(lists)
+	nprobe := int(math.Ceil(float64(candidates) / avg))
+	return max(1, min(nprobe, lists))
+}
+
+// search scans the nprobe lists closest to the query and returns the k
+// accepted entries with the smallest asymmetric PQ distance.
+func (ix *ivfpqIndex) search(query []float32, nprobe, k int, accept func(primitive.ObjectID) bool) []ivfCandidate {
+	order := make([]int, len(ix.codebooks.Coarse))
+	coarseDist := make([]float32, len(order))
+	for i, centroid := range ix.codebooks.Coarse {
+		order[i] = i
+		coarseDist[i] = squaredDistance(query, centroid)
+	}
+	sort.Slice(order, func(a, b int) bool { return coarseDist[order[a]] < coarseDist[order[b]] })
+
+	table := make([][pqCodes]float32, ix.codebooks.SubVectors)
+	var candidates []ivfCandidate
+	for _, list := range order[:min(nprobe, len(order))] {
+		r := residual(query, ix.codebooks.Coarse[list])
+		for m, codebook := range ix.codebooks.Codebooks {
+			part := r[m*ix.subDim : (m+1)*ix.subDim]
+			for c, centroid := range codebook {
+				table[m][c] = squaredDistance(part, centroid)
+			}
+		}
+
+		for _, entry := range ix.lists[list] {
+			if !accept(entry.id) {
+				continue
+			}
+			var distance float32
+			for m, c := range entry.code {
+				distance += table[m][c]
+			}
+			candidates = append(candidates, ivfCandidate{id: entry.id, distance: distance})
+		}
+	}
+
+	sort.Slice(candidates, func(a, b int) bool { return candidates[a].distance < candidates[b].distance })
+	return candidates[:min(k, len(candidates))]
+}
+
+func residual(v, centroid []float32) []float32 {
+	out := make([]float32, len(v))
+	for i := range v {
+		out[i] = v[i] - centroid[i]
+	}
+	return out
+}
+
+func saveCodebooks(path string, codebooks ivfpqCodebooks) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create index directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create codebook file: %w", err)
+	}
+	if err := gob.NewEncoder(file).Encode(codebooks); err != nil {
+		file.Close()
+		return fmt.Errorf("encode codebooks: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close codebook file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace codebook file: %w", err)
+	}
+	return nil
+}
+
+// loadCodebooks returns ok=false when no index has been trained yet.
+func loadCodebooks(path string) (ivfpqCodebooks, bool, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ivfpqCodebooks{}, false, nil
+	}
+	if err != nil {
+		return ivfpqCodebooks{}, false, fmt.Errorf("open codebook file: %w", err)
+	}
+	defer file.Close()
+
+	var codebooks ivfpqCodebooks
+	if err := gob.NewDecoder(file).Decode(&codebooks); err != nil {
+		return ivfpqCodebooks{}, false, fmt.Errorf("decode codebooks: %w", err)
+	}
+	return codebooks, true, nil
+}
@@ -0,0 +1,145 @@
+package document
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func randomVectors(n, dim int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		vectors[i] = make([]float32, dim)
+		for j := range vectors[i] {
+			vectors[i][j] = float32(rng.NormFloat64())
+		}
+	}
+	return vectors
+}
+
+func TestKMeansFindsSeparatedClusters(t *testing.T) {
+	centers := [][]float32{{0, 0}, {10, 10}, {-10, 10}}
+	noise := randomVectors(90, 2, 7)
+	vectors := make([][]float32, len(noise))
+	for i, n := range noise {
+		c := centers[i%len(centers)]
+		vectors[i] = []float32{c[0] + n[0]*0.5, c[1] + n[1]*0.5}
+	}
+
+	centroids := kmeans(vectors, 3, 20, rand.New(rand.NewSource(1)))
+	if len(centroids) != 3 {
+		t.Fatalf("got %d centroids, want 3", len(centroids))
+	}
+	for _, center := range centers {
+		nearest := centroids[nearestCentroid(centroids, center)]
+		if d := squaredDistance(center, nearest); d > 1 {
+			t.Errorf("no centroid near %v: nearest %v at squared distance %v", center, nearest, d)
+		}
+	}
+}
+
+func TestKMeansCapsKAtVectorCount(t *testing.T) {
+	centroids := kmeans(randomVectors(3, 4, 1), 10, 5, rand.New(rand.NewSource(1)))
+	if len(centroids) != 3 {
+		t.Errorf("got %d centroids for 3 vectors, want 3", len(centroids))
+	}
+}
+
+func TestIVFPQSearchFindsExactVector(t *testing.T) {
+	vectors := randomVectors(200, 8, 3)
+	codebooks, err := trainIVFPQ(vectors, 4, 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := newIVFPQIndex(codebooks)
+	ids := make([]primitive.ObjectID, len(vectors))
+	for i, v := range vectors {
+		ids[i] = primitive.NewObjectID()
+		index.add(ids[i], v)
+	}
+	acceptAll := func(primitive.ObjectID) bool { return true }
+
+	hits := 0
+	for i := 0; i < len(vectors); i += 10 {
+		results := index.search(vectors[i], len(codebooks.Coarse), 5, acceptAll)
+		if len(results) != 5 {
+			t.Fatalf("got %d results, want 5", len(results))
+		}
+		for _, result := range results {
+			if result.id == ids[i] {
+				hits++
+				break
+			}
+		}
+	}
+	if hits < 18 {
+		t.Errorf("query vector in its own top 5 for %d of 20 queries", hits)
+	}
+
+	index.remove(ids[0])
+	for _, result := range index.search(vectors[0], len(codebooks.Coarse), 200, acceptAll) {
+		if result.id == ids[0] {
+			t.Fatal("removed vector still returned")
+		}
+	}
+	if index.size != len(vectors)-1 {
+		t.Errorf("size = %d, want %d", index.size, len(vectors)-1)
+	}
+}
+
+func TestIVFPQProbes(t *testing.T) {
+	index := newIVFPQIndex(ivfpqCodebooks{Dimension: 2, SubVectors: 1, Coarse: make([][]float32, 10)})
+	index.size = 1000
+	cases := map[int]int{1: 1, 100: 1, 150: 2, 550: 6, 5000: 10}
+	for candidates, want := range cases {
+		if got := index.probes(candidates); got != want {
+			t.Errorf("probes(%d) = %d, want %d", candidates, got, want)
+		}
+	}
+}
+
+func TestTrainIVFPQRejectsUnevenSubVectors(t *testing.T) {
+	if _, err := trainIVFPQ(randomVectors(10, 6, 1), 2, 4, 5); err == nil {
+		t.Error("trainIVFPQ accepted 4 sub-vectors for dimension 6")
+	}
+}
+
+func TestCodebooksRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index", "codebooks.gob")
+	if _, ok, err := loadCodebooks(path); ok || err != nil {
+		t.Fatalf("loadCodebooks on missing file = %v, %v", ok, err)
+	}
+
+	codebooks, err := trainIVFPQ(randomVectors(50, 4, 2), 2, 2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveCodebooks(path, codebooks); err != nil {
+		t.Fatal(err)
+	}
+	loaded, ok, err := loadCodebooks(path)
+	if err != nil || !ok {
+		t.Fatalf("loadCodebooks = %v, %v", ok, err)
+	}
+	if !reflect.DeepEqual(loaded, codebooks) {
+		t.Error("loaded codebooks differ from the saved ones")
+	}
+}
+
+func TestScalarQuantization(t *testing.T) {
+	a := []float32{0.5, -1, 0.25, 0}
+	b := []float32{1, 0.5, -0.5, 2}
+	approx := dotScalar(quantizeScalar(a), quantizeScalar(b))
+	var exact float32
+	for i := range a {
+		exact += a[i] * b[i]
+	}
+	if math.Abs(float64(approx-exact)) > 0.02 {
+		t.Errorf("dotScalar = %v, exact %v", approx, exact)
+	}
+}
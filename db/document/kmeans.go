@@ -0,0 +1,112 @@
+package document
+
+import (
+	"math"
+	"math/rand"
+)
+
+// kmeans clusters vectors into k centroids with Lloyd's algorithm, seeded
+// with k-means++ from a fixed source so training is reproducible.
+func kmeans(vectors [][]float32, k, iterations int, rng *rand.Rand) [][]float32 {
+	if len(vectors) == 0 || k <= 0 {
+		return nil
+	}
+	k = min(k, len(vectors))
+	dim := len(vectors[0])
+
+	centroids := seedCentroids(vectors, k, rng)
+	assignments := make([]int, len(vectors))
+	for i := range assignments {
+		assignments[i] = -1
+	}
+
+	for round := 0; round < iterations; round++ {
+		changed := false
+		for i, v := range vectors {
+			nearest := nearestCentroid(centroids, v)
+			if nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for c := range sums {
+			sums[c] = make([]float64, dim)
+		}
+		for i, v := range vectors {
+			c := assignments[i]
+			counts[c]++
+			for j, x := range v {
+				sums[c][j] += float64(x)
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				// Re-seed empty clusters on a random point to keep k useful centroids.
+				copy(centroids[c], vectors[rng.Intn(len(vectors))])
+				continue
+			}
+			for j := range centroids[c] {
+				centroids[c][j] = float32(sums[c][j] / float64(counts[c]))
+			}
+		}
+	}
+	return centroids
+}
+
+func seedCentroids(vectors [][]float32, k int, rng *rand.Rand) [][]float32 {
+	centroids := make([][]float32, 0, k)
+	centroids = append(centroids, cloneFloat32(vectors[rng.Intn(len(vectors))]))
+
+	distances := make([]float64, len(vectors))
+	for i := range distances {
+		distances[i] = math.Inf(1)
+	}
+	for len(centroids) < k {
+		last := centroids[len(centroids)-1]
+		var total float64
+		for i, v := range vectors {
+			if d := float64(squaredDistance(v, last)); d < distances[i] {
+				distances[i] = d
+			}
+			total += distances[i]
+		}
+		if total == 0 {
+			centroids = append(centroids, cloneFloat32(vectors[rng.Intn(len(vectors))]))
+			continue
+		}
+
+		target := rng.Float64() * total
+		chosen := len(vectors) - 1
+		for i, d := range distances {
+			target -= d
+			if target <= 0 {
+				chosen = i
+				break
+			}
+		}
+		centroids = append(centroids, cloneFloat32(vectors[chosen]))
+	}
+	return centroids
+}
+
+func nearestCentroid(centroids [][]float32, v []float32) int {
+	best, bestDist := 0, float32(math.Inf(1))
+	for c, centroid := range centroids {
+		if d := squaredDistance(v, centroid); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+func cloneFloat32(v []float32) []float32 {
+	out := make([]float32, len(v))
+	copy(out, v)
+	return out
+}
@@ -0,0 +1,605 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"vector-database/config"
+	"vector-database/model"
+	"vector-database/tenant"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	codebookFile          = "ivfpq.gob"
+	defaultIVFIterations  = 20
+	defaultPQSubDimension = 8
+)
+
+// Trainer is implemented by stores whose ANN index is learned from the
+// stored vectors rather than maintained by the database.
+type Trainer interface {
+	TrainIndex(ctx context.Context) (model.IndexInfo, error)
+}
+
+type memoryEntry struct {
+	doc  model.Document
+	code scalarCode
+}
+
+// memoryStore keeps documents in process. Until an IVF-PQ index is trained,
+// searches scan every vector for candidates and rescore them with the
+// float32 embeddings. With scalar quantization the scan reads int8 codes,
+// otherwise the float32 embeddings themselves. When a path is configured every mutation is written
+// to a WAL before it is applied, and periodic snapshots bound recovery time.
+type memoryStore struct {
+	cfg      config.MongoDB
+	storeCfg config.Store
+
+	mu      sync.RWMutex
+	entries map[primitive.ObjectID]*memoryEntry
+	ivf     *ivfpqIndex
+	wal     *wal
+	seq     uint64
+
+	snapshotMu sync.Mutex
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewMemoryStore returns an embedded Store. With storeCfg.Path set it
+// recovers from the last snapshot plus the WAL records written after it.
+func NewMemoryStore(cfg config.MongoDB, storeCfg config.Store) (Store, error) {
+	m := &memoryStore{
+		cfg:      cfg,
+		storeCfg: storeCfg,
+		entries:  map[primitive.ObjectID]*memoryEntry{},
+	}
+	if storeCfg.Path == "" {
+		return m, nil
+	}
+
+	if err := os.MkdirAll(storeCfg.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("create store directory: %w", err)
+	}
+	if err := m.recover(); err != nil {
+		return nil, err
+	}
+
+	if storeCfg.SnapshotInterval > 0 {
+		m.stop = make(chan struct{})
+		m.done = make(chan struct{})
+		go m.snapshotLoop(storeCfg.SnapshotInterval)
+	}
+	return m, nil
+}
+
+func (m *memoryStore) recover() error {
+	snap, ok, err := readSnapshot(m.path(snapshotFile))
+	if err != nil {
+		return err
+	}
+
+	codebooks := snap.Codebooks
+	if codebooks == nil {
+		loaded, found, err := loadCodebooks(m.path(codebookFile))
+		if err != nil {
+			return err
+		}
+		if found {
+			codebooks = &loaded
+		}
+	}
+	if codebooks != nil {
+		if codebooks.Dimension != m.cfg.EmbeddingDimension {
+			return fmt.Errorf("trained index has dimension %d, expected %d", codebooks.Dimension, m.cfg.EmbeddingDimension)
+		}
+		m.ivf = newIVFPQIndex(*codebooks)
+	}
+
+	if ok {
+		m.seq = snap.Seq
+		for _, stored := range snap.Documents {
+			doc := model.Document{
+				ID:        primitive.ObjectID(stored.ID),
+				Content:   stored.Content,
+				Embedding: stored.Embedding,
+				Metadata:  stored.Metadata,
+				Tenant:    ownerOrDefault(stored.Tenant),
+			}
+			m.entries[doc.ID] = m.newEntry(doc)
+		}
+	}
+
+	if m.ivf != nil {
+		if ok && snap.Codebooks != nil && len(snap.Lists) == len(m.ivf.lists) {
+			for list, entries := range snap.Lists {
+				for _, entry := range entries {
+					m.ivf.insert(list, ivfEntry{id: primitive.ObjectID(entry.ID), code: entry.Code})
+				}
+			}
+		} else {
+			for id, entry := range m.entries {
+				m.ivf.add(id, entry.doc.Embedding)
+			}
+		}
+	}
+
+	m.wal, err = openWAL(m.path(walFile))
+	if err != nil {
+		return err
+	}
+	return m.wal.replay(func(record walRecord) error {
+		if record.Seq <= m.seq {
+			return nil
+		}
+		if err := m.check(record); err != nil {
+			return err
+		}
+		m.seq = record.Seq
+		m.apply(record)
+		return nil
+	})
+}
+
+// check reports whether apply can take record, so that only records that
+// apply cleanly are logged.
+func (m *memoryStore) check(record walRecord) error {
+	switch record.Op {
+	case walInsert, walUpdate:
+		if len(record.Embedding) != m.cfg.EmbeddingDimension {
+			return fmt.Errorf("embedding dimension mismatch: expected %d, got %d", m.cfg.EmbeddingDimension, len(record.Embedding))
+		}
+	case walDelete:
+	default:
+		return fmt.Errorf("unknown wal operation %q", record.Op)
+	}
+	return nil
+}
+
+// apply mutates the in-memory state with a record check accepted. Callers
+// must hold the write lock.
+func (m *memoryStore) apply(record walRecord) {
+	switch record.Op {
+	case walInsert, walUpdate:
+		doc := model.Document{
+			ID:        record.ID,
+			Content:   record.Content,
+			Embedding: record.Embedding,
+			Metadata:  record.Metadata,
+			Tenant:    ownerOrDefault(record.Tenant),
+		}
+		m.entries[doc.ID] = m.newEntry(doc)
+		if m.ivf != nil {
+			m.ivf.remove(doc.ID)
+			m.ivf.add(doc.ID, doc.Embedding)
+		}
+	case walDelete:
+		delete(m.entries, record.ID)
+		if m.ivf != nil {
+			m.ivf.remove(record.ID)
+		}
+	}
+}
+
+// commit checks the records, logs them when persistence is enabled and
+// then applies them, so the log and memory never disagree. Callers must
+// hold the write lock.
+func (m *memoryStore) commit(records ...walRecord) error {
+	for _, record := range records {
+		if err := m.check(record); err != nil {
+			return err
+		}
+	}
+	if m.wal != nil {
+		for i := range records {
+			records[i].Seq = m.seq + uint64(i) + 1
+		}
+		if err := m.wal.append(records...); err != nil {
+			return err
+		}
+		m.seq += uint64(len(records))
+	}
+	for _, record := range records {
+		m.apply(record)
+	}
+	return nil
+}
+
+func (m *memoryStore) InsertDocument(ctx context.Context, doc model.DocumentInput, embedding []float32) (model.Document, error) {
+	docs, err := m.InsertDocuments(ctx, []model.DocumentInput{doc}, [][]float32{embedding})
+	if err != nil {
+		return model.Document{}, err
+	}
+	return docs[0], nil
+}
+
+func (m *memoryStore) InsertDocuments(ctx context.Context, docs []model.DocumentInput, embeddings [][]float32) ([]model.Document, error) {
+	if len(docs) != len(embeddings) {
+		return nil, fmt.Errorf("got %d documents but %d embeddings", len(docs), len(embeddings))
+	}
+
+	owner := tenant.FromContext(ctx)
+	records := make([]walRecord, len(docs))
+	results := make([]model.Document, len(docs))
+	for i, doc := range docs {
+		if err := doc.Validate(); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		if len(embeddings[i]) != m.cfg.EmbeddingDimension {
+			return nil, fmt.Errorf("document %d: embedding dimension mismatch: expected %d, got %d", i, m.cfg.EmbeddingDimension, len(embeddings[i]))
+		}
+		records[i] = walRecord{
+			Op:        walInsert,
+			ID:        primitive.NewObjectID(),
+			Content:   doc.Content,
+			Metadata:  doc.Metadata,
+			Embedding: cloneFloat32(embeddings[i]),
+			Tenant:    owner,
+		}
+		results[i] = model.Document{
+			ID:        records[i].ID,
+			Content:   doc.Content,
+			Embedding: records[i].Embedding,
+			Metadata:  doc.Metadata,
+			Tenant:    owner,
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.commit(records...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (m *memoryStore) UpdateDocument(ctx context.Context, id primitive.ObjectID, doc model.DocumentInput, embedding []float32) (model.Document, error) {
+	if err := doc.Validate(); err != nil {
+		return model.Document{}, err
+	}
+	if len(embedding) != m.cfg.EmbeddingDimension {
+		return model.Document{}, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", m.cfg.EmbeddingDimension, len(embedding))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	owner := tenant.FromContext(ctx)
+	if !m.owns(id, owner) {
+		return model.Document{}, ErrNotFound
+	}
+	record := walRecord{
+		Op:        walUpdate,
+		ID:        id,
+		Content:   doc.Content,
+		Metadata:  doc.Metadata,
+		Embedding: cloneFloat32(embedding),
+		Tenant:    owner,
+	}
+	if err := m.commit(record); err != nil {
+		return model.Document{}, err
+	}
+	return m.entries[id].doc, nil
+}
+
+func (m *memoryStore) DeleteDocument(ctx context.Context, id primitive.ObjectID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.owns(id, tenant.FromContext(ctx)) {
+		return ErrNotFound
+	}
+	return m.commit(walRecord{Op: walDelete, ID: id})
+}
+
+// newEntry wraps doc for the scan, quantizing its embedding when the store
+// is configured for scalar quantization.
+func (m *memoryStore) newEntry(doc model.Document) *memoryEntry {
+	entry := &memoryEntry{doc: doc}
+	if m.cfg.Quantization == config.QuantizationScalar {
+		entry.code = quantizeScalar(doc.Embedding)
+	}
+	return entry
+}
+
+func (m *memoryStore) GetDocuments(ctx context.Context, ids []primitive.ObjectID) ([]model.Document, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	owner := tenant.FromContext(ctx)
+	docs := make([]model.Document, 0, len(ids))
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] || !m.owns(id, owner) {
+			continue
+		}
+		seen[id] = true
+		docs = append(docs, m.entries[id].doc)
+	}
+	return docs, nil
+}
+
+// owns reports whether id exists within tenant owner. Callers must hold
+// the lock.
+func (m *memoryStore) owns(id primitive.ObjectID, owner string) bool {
+	entry, ok := m.entries[id]
+	return ok && entry.doc.Tenant == owner
+}
+
+// ownerOrDefault assigns documents logged before tenants existed to
+// tenant.Default.
+func ownerOrDefault(owner string) string {
+	if owner == "" {
+		return tenant.Default
+	}
+	return owner
+}
+
+func (m *memoryStore) SimilaritySearch(ctx context.Context, query model.VectorQuery) ([]model.Document, error) {
+	if err := query.Validate(m.cfg.EmbeddingDimension); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	owner := tenant.FromContext(ctx)
+	accept := func(id primitive.ObjectID) bool {
+		entry, ok := m.entries[id]
+		return ok && entry.doc.Tenant == owner
+	}
+
+	candidates := query.Candidates()
+	var ids []primitive.ObjectID
+	if m.ivf != nil {
+		for _, c := range m.ivf.search(query.QueryVector, m.ivf.probes(candidates), candidates, accept) {
+			ids = append(ids, c.id)
+		}
+	} else {
+		ids = m.scan(query.QueryVector, candidates, accept)
+	}
+
+	results := make([]model.Document, 0, len(ids))
+	for _, id := range ids {
+		doc := m.entries[id].doc
+		doc.Score = CosineScore(query.QueryVector, doc.Embedding)
+		results = append(results, doc)
+	}
+	SortByScore(results)
+	return query.Page(results), nil
+}
+
+// Status counts the documents of the context's tenant and reports which
+// index serves searches.
+func (m *memoryStore) Status(ctx context.Context) (model.StoreStatus, error) {
+	index, _ := m.IndexStatus(ctx)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	owner := tenant.FromContext(ctx)
+	var count int64
+	for _, entry := range m.entries {
+		if entry.doc.Tenant == owner {
+			count++
+		}
+	}
+	return model.StoreStatus{Type: config.StoreMemory, Documents: count, Index: index}, nil
+}
+
+// IndexStatus reports which index serves searches. The scan needs no
+// training, so the store is always queryable.
+func (m *memoryStore) IndexStatus(_ context.Context) (model.IndexStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	index := model.IndexStatus{Name: "flat", Status: "READY", Queryable: true}
+	switch {
+	case m.ivf != nil:
+		index.Name = "ivfpq"
+	case m.cfg.Quantization == config.QuantizationScalar:
+		index.Name = "scalar"
+	}
+	return index, nil
+}
+
+// scan ranks every accepted document and keeps the best k as rescoring
+// candidates. Scalar-quantized stores rank by the approximate int8 dot
+// product, the others by exact cosine similarity.
+func (m *memoryStore) scan(query []float32, k int, accept func(primitive.ObjectID) bool) []primitive.ObjectID {
+	scalar := m.cfg.Quantization == config.QuantizationScalar
+	var queryCode scalarCode
+	if scalar {
+		queryCode = quantizeScalar(query)
+	}
+
+	type scored struct {
+		id    primitive.ObjectID
+		score float32
+	}
+	var all []scored
+	for id, entry := range m.entries {
+		if !accept(id) {
+			continue
+		}
+		var score float32
+		if scalar {
+			score = dotScalar(queryCode, entry.code) / entry.code.norm()
+		} else {
+			score = float32(cosine(query, entry.doc.Embedding))
+		}
+		all = append(all, scored{id: id, score: score})
+	}
+	sort.Slice(all, func(a, b int) bool { return all[a].score > all[b].score })
+
+	ids := make([]primitive.ObjectID, 0, min(k, len(all)))
+	for _, s := range all[:min(k, len(all))] {
+		ids = append(ids, s.id)
+	}
+	return ids
+}
+
+// TrainIndex learns IVF-PQ codebooks from every stored vector, indexes the
+// store with them and persists the codebooks.
+func (m *memoryStore) TrainIndex(_ context.Context) (model.IndexInfo, error) {
+	m.mu.RLock()
+	vectors := make([][]float32, 0, len(m.entries))
+	for _, entry := range m.entries {
+		vectors = append(vectors, entry.doc.Embedding)
+	}
+	m.mu.RUnlock()
+
+	if len(vectors) == 0 {
+		return model.IndexInfo{}, ErrEmptyStore
+	}
+
+	lists := m.storeCfg.IVF.Lists
+	if lists <= 0 {
+		lists = int(math.Max(1, math.Sqrt(float64(len(vectors)))))
+	}
+	subVectors := m.storeCfg.IVF.SubVectors
+	if subVectors <= 0 {
+		subVectors = defaultSubVectors(m.cfg.EmbeddingDimension)
+	}
+	iterations := m.storeCfg.IVF.Iterations
+	if iterations <= 0 {
+		iterations = defaultIVFIterations
+	}
+
+	codebooks, err := trainIVFPQ(vectors, lists, subVectors, iterations)
+	if err != nil {
+		return model.IndexInfo{}, fmt.Errorf("train ivfpq index: %w", err)
+	}
+	if m.storeCfg.Path != "" {
+		if err := saveCodebooks(m.path(codebookFile), codebooks); err != nil {
+			return model.IndexInfo{}, err
+		}
+	}
+
+	m.mu.Lock()
+	m.ivf = newIVFPQIndex(codebooks)
+	for id, entry := range m.entries {
+		m.ivf.add(id, entry.doc.Embedding)
+	}
+	info := model.IndexInfo{
+		Type:       "ivfpq",
+		Lists:      len(codebooks.Coarse),
+		SubVectors: codebooks.SubVectors,
+		Vectors:    m.ivf.size,
+	}
+	m.mu.Unlock()
+
+	if m.wal != nil {
+		if err := m.Snapshot(); err != nil {
+			return model.IndexInfo{}, err
+		}
+	}
+	return info, nil
+}
+
+// Snapshot writes every document and the index lists to disk and then
+// truncates the WAL, whose records the snapshot now covers. Writers are
+// blocked for the duration; readers are not.
+func (m *memoryStore) Snapshot() error {
+	if m.wal == nil {
+		return nil
+	}
+	m.snapshotMu.Lock()
+	defer m.snapshotMu.Unlock()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snap := snapshot{
+		Seq:       m.seq,
+		Documents: make([]snapshotDocument, 0, len(m.entries)),
+	}
+	for id, entry := range m.entries {
+		snap.Documents = append(snap.Documents, snapshotDocument{
+			ID:        id,
+			Content:   entry.doc.Content,
+			Metadata:  entry.doc.Metadata,
+			Embedding: entry.doc.Embedding,
+			Tenant:    entry.doc.Tenant,
+		})
+	}
+	if m.ivf != nil {
+		codebooks := m.ivf.codebooks
+		snap.Codebooks = &codebooks
+		snap.Lists = make([][]snapshotListEntry, len(m.ivf.lists))
+		for list, entries := range m.ivf.lists {
+			for _, entry := range entries {
+				snap.Lists[list] = append(snap.Lists[list], snapshotListEntry{ID: entry.id, Code: entry.code})
+			}
+		}
+	}
+
+	if err := writeSnapshot(m.path(snapshotFile), snap); err != nil {
+		return err
+	}
+	return m.wal.reset()
+}
+
+func (m *memoryStore) snapshotLoop(interval time.Duration) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastSeq uint64
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.mu.RLock()
+			seq := m.seq
+			m.mu.RUnlock()
+			if seq == lastSeq {
+				continue
+			}
+			// A failed snapshot leaves the WAL intact, so the next tick retries.
+			if err := m.Snapshot(); err == nil {
+				lastSeq = seq
+			}
+		}
+	}
+}
+
+// Close stops periodic snapshots, writes a final snapshot and closes the WAL.
+func (m *memoryStore) Close() error {
+	if m.wal == nil {
+		return nil
+	}
+	if m.stop != nil {
+		close(m.stop)
+		<-m.done
+	}
+	if err := m.Snapshot(); err != nil {
+		return err
+	}
+	return m.wal.close()
+}
+
+func (m *memoryStore) path(name string) string {
+	return filepath.Join(m.storeCfg.Path, name)
+}
+
+// defaultSubVectors splits the vector into 8-dimensional sub-vectors when
+// possible, falling back to smaller ones for odd dimensions.
+func defaultSubVectors(dimension int) int {
+	for subDim := defaultPQSubDimension; subDim > 1; subDim /= 2 {
+		if dimension%subDim == 0 {
+			return dimension / subDim
+		}
+	}
+	return dimension
+}
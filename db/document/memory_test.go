@@ -0,0 +1,164 @@
+package document
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"vector-database/config"
+	"vector-database/model"
+	"vector-database/tenant"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestMemoryStore(t *testing.T, dim int) *memoryStore {
+	t.Helper()
+	store, err := NewMemoryStore(config.MongoDB{EmbeddingDimension: dim}, config.Store{Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.(*memoryStore).Close() })
+	return store.(*memoryStore)
+}
+
+func TestMemoryStoreKeepsTenantsApart(t *testing.T) {
+	store := newTestMemoryStore(t, 2)
+	acme := tenant.NewContext(context.Background(), "acme")
+	other := tenant.NewContext(context.Background(), "other")
+
+	secret, err := store.InsertDocument(acme, model.DocumentInput{Content: "acme only"}, []float32{1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.InsertDocument(other, model.DocumentInput{Content: "other"}, []float32{0, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := store.GetDocuments(other, []primitive.ObjectID{secret.ID}); err != nil || len(got) != 0 {
+		t.Errorf("other tenant got acme's document: %v, %v", got, err)
+	}
+	docs, err := store.SimilaritySearch(other, model.VectorQuery{QueryVector: []float32{1, 0}, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs {
+		if doc.ID == secret.ID {
+			t.Errorf("other tenant's search returned acme's document")
+		}
+	}
+	if len(docs) != 1 {
+		t.Errorf("other tenant's search returned %d documents, want its own one", len(docs))
+	}
+	if status, _ := store.Status(other); status.Documents != 1 {
+		t.Errorf("other tenant's status counts %d documents, want 1", status.Documents)
+	}
+}
+
+func TestMemoryStoreHonoursQuantization(t *testing.T) {
+	cases := []struct {
+		quantization string
+		index        string
+		coded        bool
+	}{
+		{"", "flat", false},
+		{config.QuantizationNone, "flat", false},
+		{config.QuantizationScalar, "scalar", true},
+	}
+	for _, tc := range cases {
+		store, err := NewMemoryStore(config.MongoDB{EmbeddingDimension: 2, Quantization: tc.quantization}, config.Store{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := store.(*memoryStore)
+		ctx := context.Background()
+		near, err := m.InsertDocument(ctx, model.DocumentInput{Content: "near"}, []float32{1, 0.1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.InsertDocument(ctx, model.DocumentInput{Content: "far"}, []float32{0, 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		if coded := m.entries[near.ID].code.values != nil; coded != tc.coded {
+			t.Errorf("quantization %q: entry coded = %v, want %v", tc.quantization, coded, tc.coded)
+		}
+		if index, _ := m.IndexStatus(ctx); index.Name != tc.index {
+			t.Errorf("quantization %q: index = %q, want %q", tc.quantization, index.Name, tc.index)
+		}
+		docs, err := m.SimilaritySearch(ctx, model.VectorQuery{QueryVector: []float32{1, 0}, Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(docs) != 1 || docs[0].ID != near.ID {
+			t.Errorf("quantization %q: search returned %v, want the near document", tc.quantization, docs)
+		}
+	}
+}
+
+// newMisrankedStore holds documents at growing angles from the x axis.
+// The int8 code of "best", the exact nearest neighbour of [1 0], is
+// replaced with the code of a vector at 0.25 rad, so the scalar scan
+// ranks it third, as a quantization error would.
+func newMisrankedStore(t *testing.T) *memoryStore {
+	t.Helper()
+	store, err := NewMemoryStore(config.MongoDB{EmbeddingDimension: 2, Quantization: config.QuantizationScalar}, config.Store{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := store.(*memoryStore)
+	at := func(angle float64) []float32 {
+		return []float32{float32(math.Cos(angle)), float32(math.Sin(angle))}
+	}
+	ctx := context.Background()
+	for _, doc := range []struct {
+		content string
+		angle   float64
+	}{{"best", 0}, {"d1", 0.1}, {"d2", 0.2}, {"d3", 0.3}, {"d4", 1.0}, {"d5", 1.5}} {
+		inserted, err := m.InsertDocument(ctx, model.DocumentInput{Content: doc.content}, at(doc.angle))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.content == "best" {
+			m.entries[inserted.ID].code = quantizeScalar(at(0.25))
+		}
+	}
+	return m
+}
+
+func TestMemoryStoreExactSearchRescoresOversampledCandidates(t *testing.T) {
+	store := newMisrankedStore(t)
+	query := []float32{1, 0}
+	cases := []struct {
+		name  string
+		query model.VectorQuery
+		want  []string
+	}{
+		// One candidate: the scan's top pick is returned as is.
+		{"approximate", model.VectorQuery{QueryVector: query, Limit: 1, NumCandidates: 1}, []string{"d1"}},
+		// FetchLimit oversamples to four candidates, which reach "best".
+		{"exact", model.VectorQuery{QueryVector: query, Limit: 1, NumCandidates: 1, Exact: true}, []string{"best"}},
+		{"exact page", model.VectorQuery{QueryVector: query, Limit: 3, NumCandidates: 3, Exact: true}, []string{"best", "d1", "d2"}},
+		{"exact offset", model.VectorQuery{QueryVector: query, Limit: 2, NumCandidates: 2, Offset: 1, Exact: true}, []string{"d1", "d2"}},
+	}
+	for _, tc := range cases {
+		docs, err := store.SimilaritySearch(context.Background(), tc.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		got := make([]string, len(docs))
+		for i, doc := range docs {
+			got[i] = doc.Content
+			if want := CosineScore(query, doc.Embedding); doc.Score != want {
+				t.Errorf("%s: %s scored %v, want the exact %v", tc.name, doc.Content, doc.Score, want)
+			}
+			if i > 0 && docs[i-1].Score < doc.Score {
+				t.Errorf("%s: results are not in descending score order: %v", tc.name, got)
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: results = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
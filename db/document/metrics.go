@@ -0,0 +1,11 @@
+package document
+
+import "vector-database/metrics"
+
+// aggregateDuration times Mongo aggregations, including draining the cursor.
+var aggregateDuration = metrics.NewHistogramVec(
+	"mongo_aggregate_duration_seconds",
+	"Duration of Mongo aggregation pipelines, including reading the results.",
+	metrics.LatencyBuckets,
+	"operation",
+)
@@ -0,0 +1,81 @@
+package document
+
+import "math"
+
+// scalarCode is an int8 scalar quantization of a vector: v[i] ≈ values[i] * scale.
+type scalarCode struct {
+	values []int8
+	scale  float32
+}
+
+func quantizeScalar(vector []float32) scalarCode {
+	var maxAbs float32
+	for _, v := range vector {
+		if a := float32(math.Abs(float64(v))); a > maxAbs {
+			maxAbs = a
+		}
+	}
+
+	code := scalarCode{values: make([]int8, len(vector))}
+	if maxAbs == 0 {
+		return code
+	}
+	code.scale = maxAbs / 127
+	for i, v := range vector {
+		code.values[i] = int8(math.Round(float64(v / code.scale)))
+	}
+	return code
+}
+
+// dotScalar approximates the dot product of two quantized vectors with
+// integer arithmetic.
+func dotScalar(a, b scalarCode) float32 {
+	var sum int32
+	for i := range a.values {
+		sum += int32(a.values[i]) * int32(b.values[i])
+	}
+	return float32(sum) * a.scale * b.scale
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// CosineScore maps cosine similarity to [0, 1] the same way Atlas reports
+// vectorSearchScore for the cosine similarity function.
+func CosineScore(a, b []float32) float64 {
+	return (1 + cosine(a, b)) / 2
+}
+
+func squaredDistance(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
+
+// norm returns the Euclidean norm of the dequantized vector.
+func (c scalarCode) norm() float32 {
+	var sum int32
+	for _, v := range c.values {
+		sum += int32(v) * int32(v)
+	}
+	if sum == 0 {
+		return 1
+	}
+	return float32(math.Sqrt(float64(sum))) * c.scale
+}
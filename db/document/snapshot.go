@@ -0,0 +1,94 @@
+package document
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+)
+
+const snapshotFile = "snapshot.gob"
+
+// snapshot is a point-in-time image of the memory store: every document,
+// the trained codebooks and the inverted list assignments. Seq is the last
+// WAL sequence number it includes.
+type snapshot struct {
+	Seq       uint64
+	Documents []snapshotDocument
+	Codebooks *ivfpqCodebooks
+	Lists     [][]snapshotListEntry
+}
+
+type snapshotDocument struct {
+	ID        [12]byte
+	Content   string
+	Metadata  map[string]interface{}
+	Embedding []float32
+	Tenant    string
+}
+
+type snapshotListEntry struct {
+	ID   [12]byte
+	Code []byte
+}
+
+// writeSnapshot stores the snapshot followed by a CRC-32C trailer, via a
+// temporary file and rename so a crash never leaves a partial snapshot.
+func writeSnapshot(path string, snap snapshot) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snap); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	trailer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(trailer, crc32.Checksum(buf.Bytes(), walChecksumTable))
+	buf.Write(trailer)
+
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("sync snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// readSnapshot returns ok=false when no snapshot has been written yet.
+func readSnapshot(path string) (snapshot, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshot{}, false, nil
+	}
+	if err != nil {
+		return snapshot{}, false, fmt.Errorf("read snapshot: %w", err)
+	}
+	if len(data) < 4 {
+		return snapshot{}, false, errors.New("snapshot is truncated")
+	}
+
+	body, trailer := data[:len(data)-4], data[len(data)-4:]
+	if crc32.Checksum(body, walChecksumTable) != binary.LittleEndian.Uint32(trailer) {
+		return snapshot{}, false, errors.New("snapshot checksum mismatch")
+	}
+
+	var snap snapshot
+	if err := gob.NewDecoder(bytes.NewReader(body)).Decode(&snap); err != nil {
+		return snapshot{}, false, fmt.Errorf("decode snapshot: %w", err)
+	}
+	return snap, true, nil
+}
@@ -4,19 +4,40 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"vector-database/config"
 	"vector-database/model"
+	"vector-database/tenant"
+	"vector-database/tracing"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrNotFound is returned when no document has the requested id.
+	ErrNotFound = errors.New("document not found")
+	// ErrEmptyStore is returned when training an index on a store with no
+	// documents.
+	ErrEmptyStore = errors.New("cannot train an index on an empty store")
 )
 
 // Store defines CRUD and search operations over the documents collection.
 type Store interface {
 	InsertDocument(ctx context.Context, doc model.DocumentInput, embedding []float32) (model.Document, error)
+	InsertDocuments(ctx context.Context, docs []model.DocumentInput, embeddings [][]float32) ([]model.Document, error)
+	UpdateDocument(ctx context.Context, id primitive.ObjectID, doc model.DocumentInput, embedding []float32) (model.Document, error)
+	DeleteDocument(ctx context.Context, id primitive.ObjectID) error
+	// GetDocuments returns the documents with the given ids in one round
+	// trip. Unknown ids are left out rather than reported.
+	GetDocuments(ctx context.Context, ids []primitive.ObjectID) ([]model.Document, error)
 	SimilaritySearch(ctx context.Context, query model.VectorQuery) ([]model.Document, error)
+	Status(ctx context.Context) (model.StoreStatus, error)
+	IndexStatus(ctx context.Context) (model.IndexStatus, error)
 }
 
 type mongoStore struct {
@@ -32,7 +53,13 @@ func NewStore(collection *mongo.Collection, cfg config.MongoDB) Store {
 	}
 }
 
-func (m *mongoStore) InsertDocument(ctx context.Context, doc model.DocumentInput, embedding []float32) (model.Document, error) {
+func (m *mongoStore) InsertDocument(ctx context.Context, doc model.DocumentInput, embedding []float32) (_ model.Document, err error) {
+	ctx, span := m.startSpan(ctx, "InsertDocument")
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	if err := doc.Validate(); err != nil {
 		return model.Document{}, err
 	}
@@ -40,15 +67,7 @@ func (m *mongoStore) InsertDocument(ctx context.Context, doc model.DocumentInput
 		return model.Document{}, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", m.cfg.EmbeddingDimension, len(embedding))
 	}
 
-	payload := bson.M{
-		"content":   doc.Content,
-		"embedding": float32ToFloat64(embedding),
-	}
-	if len(doc.Metadata) > 0 {
-		payload["metadata"] = doc.Metadata
-	}
-
-	res, err := m.collection.InsertOne(ctx, payload)
+	res, err := m.collection.InsertOne(ctx, documentPayload(doc, embedding, tenant.FromContext(ctx)))
 	if err != nil {
 		return model.Document{}, fmt.Errorf("insert document: %w", err)
 	}
@@ -66,7 +85,149 @@ func (m *mongoStore) InsertDocument(ctx context.Context, doc model.DocumentInput
 	}, nil
 }
 
-func (m *mongoStore) SimilaritySearch(ctx context.Context, query model.VectorQuery) ([]model.Document, error) {
+func (m *mongoStore) InsertDocuments(ctx context.Context, docs []model.DocumentInput, embeddings [][]float32) (_ []model.Document, err error) {
+	ctx, span := m.startSpan(ctx, "InsertDocuments")
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+	span.SetAttributes(attribute.Int("db.operation.batch.size", len(docs)))
+
+	if len(docs) != len(embeddings) {
+		return nil, fmt.Errorf("got %d documents but %d embeddings", len(docs), len(embeddings))
+	}
+
+	owner := tenant.FromContext(ctx)
+	payloads := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		if err := doc.Validate(); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		if len(embeddings[i]) != m.cfg.EmbeddingDimension {
+			return nil, fmt.Errorf("document %d: embedding dimension mismatch: expected %d, got %d", i, m.cfg.EmbeddingDimension, len(embeddings[i]))
+		}
+		payloads[i] = documentPayload(doc, embeddings[i], owner)
+	}
+
+	res, err := m.collection.InsertMany(ctx, payloads)
+	if err != nil {
+		return nil, fmt.Errorf("insert documents: %w", err)
+	}
+
+	results := make([]model.Document, len(docs))
+	for i, insertedID := range res.InsertedIDs {
+		id, ok := insertedID.(primitive.ObjectID)
+		if !ok {
+			return nil, errors.New("failed to convert inserted id to ObjectID")
+		}
+		results[i] = model.Document{
+			ID:        id,
+			Content:   docs[i].Content,
+			Embedding: embeddings[i],
+			Metadata:  docs[i].Metadata,
+		}
+	}
+	return results, nil
+}
+
+func (m *mongoStore) UpdateDocument(ctx context.Context, id primitive.ObjectID, doc model.DocumentInput, embedding []float32) (model.Document, error) {
+	if err := doc.Validate(); err != nil {
+		return model.Document{}, err
+	}
+	if len(embedding) != m.cfg.EmbeddingDimension {
+		return model.Document{}, fmt.Errorf("embedding dimension mismatch: expected %d, got %d", m.cfg.EmbeddingDimension, len(embedding))
+	}
+
+	update := bson.D{{Key: "$set", Value: documentPayload(doc, embedding, tenant.FromContext(ctx))}}
+	if len(doc.Metadata) == 0 {
+		update = append(update, bson.E{Key: "$unset", Value: bson.D{{Key: "metadata", Value: ""}}})
+	}
+
+	res, err := m.collection.UpdateOne(ctx, ownedBy(ctx, id), update)
+	if err != nil {
+		return model.Document{}, fmt.Errorf("update document: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return model.Document{}, ErrNotFound
+	}
+
+	return model.Document{
+		ID:        id,
+		Content:   doc.Content,
+		Embedding: embedding,
+		Metadata:  doc.Metadata,
+	}, nil
+}
+
+func (m *mongoStore) DeleteDocument(ctx context.Context, id primitive.ObjectID) error {
+	res, err := m.collection.DeleteOne(ctx, ownedBy(ctx, id))
+	if err != nil {
+		return fmt.Errorf("delete document: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// storedDocument is the shape documents are read back in.
+type storedDocument struct {
+	ID        primitive.ObjectID     `bson:"_id"`
+	Content   string                 `bson:"content"`
+	Embedding bson.RawValue          `bson:"embedding"`
+	Metadata  map[string]interface{} `bson:"metadata"`
+}
+
+func (d storedDocument) decode() (model.Document, error) {
+	embedding, err := decodeEmbedding(d.Embedding)
+	if err != nil {
+		return model.Document{}, fmt.Errorf("decode document %s: %w", d.ID.Hex(), err)
+	}
+	return model.Document{
+		ID:        d.ID,
+		Content:   d.Content,
+		Embedding: embedding,
+		Metadata:  d.Metadata,
+	}, nil
+}
+
+func (m *mongoStore) GetDocuments(ctx context.Context, ids []primitive.ObjectID) ([]model.Document, error) {
+	filter := bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}},
+		{Key: "tenant", Value: tenant.FromContext(ctx)},
+	}
+	cursor, err := m.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("find documents: %w", err)
+	}
+	var stored []storedDocument
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, fmt.Errorf("read documents: %w", err)
+	}
+
+	docs := make([]model.Document, 0, len(stored))
+	for _, doc := range stored {
+		decoded, err := doc.decode()
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, decoded)
+	}
+	return docs, nil
+}
+
+func (m *mongoStore) SimilaritySearch(ctx context.Context, query model.VectorQuery) (_ []model.Document, err error) {
+	ctx, span := m.startSpan(ctx, "SimilaritySearch")
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+	span.SetAttributes(
+		attribute.Int("vector.limit", query.FetchLimit()),
+		attribute.Int("vector.candidates", query.Candidates()),
+		attribute.Bool("vector.exact", query.Exact),
+	)
+
 	if err := query.Validate(m.cfg.EmbeddingDimension); err != nil {
 		return nil, err
 	}
@@ -76,11 +237,8 @@ func (m *mongoStore) SimilaritySearch(ctx context.Context, query model.VectorQue
 		{Key: "path", Value: "embedding"},
 		{Key: "queryVector", Value: float32ToFloat64(query.QueryVector)},
 		{Key: "numCandidates", Value: query.Candidates()},
-		{Key: "limit", Value: query.Limit},
-	}
-
-	if len(query.Filter) > 0 {
-		vectorStage = append(vectorStage, bson.E{Key: "filter", Value: query.Filter})
+		{Key: "limit", Value: query.FetchLimit()},
+		{Key: "filter", Value: tenantFilter(ctx)},
 	}
 
 	pipeline := mongo.Pipeline{
@@ -93,6 +251,9 @@ func (m *mongoStore) SimilaritySearch(ctx context.Context, query model.VectorQue
 		}}},
 	}
 
+	start := time.Now()
+	defer aggregateDuration.ObserveSince(start, "vector_search")
+
 	cursor, err := m.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, fmt.Errorf("vector search aggregate: %w", err)
@@ -104,18 +265,22 @@ func (m *mongoStore) SimilaritySearch(ctx context.Context, query model.VectorQue
 		var doc struct {
 			ID        primitive.ObjectID     `bson:"_id"`
 			Content   string                 `bson:"content"`
-			Embedding []float64              `bson:"embedding"`
+			Embedding bson.RawValue          `bson:"embedding"`
 			Metadata  map[string]interface{} `bson:"metadata"`
 			Score     float64                `bson:"score"`
 		}
 		if err := cursor.Decode(&doc); err != nil {
 			return nil, fmt.Errorf("decode vector search result: %w", err)
 		}
+		embedding, err := decodeEmbedding(doc.Embedding)
+		if err != nil {
+			return nil, fmt.Errorf("decode vector search result %s: %w", doc.ID.Hex(), err)
+		}
 
 		results = append(results, model.Document{
 			ID:        doc.ID,
 			Content:   doc.Content,
-			Embedding: float64ToFloat32(doc.Embedding),
+			Embedding: embedding,
 			Metadata:  doc.Metadata,
 			Score:     doc.Score,
 		})
@@ -125,7 +290,75 @@ func (m *mongoStore) SimilaritySearch(ctx context.Context, query model.VectorQue
 		return nil, fmt.Errorf("iterate vector search cursor: %w", err)
 	}
 
-	return results, nil
+	if query.Exact {
+		rescore(results, query.QueryVector)
+	}
+	SortByScore(results)
+	return query.Page(results), nil
+}
+
+// Status counts the documents of the context's tenant and reports the
+// index state.
+func (m *mongoStore) Status(ctx context.Context) (model.StoreStatus, error) {
+	count, err := m.collection.CountDocuments(ctx, bson.D{{Key: "tenant", Value: tenant.FromContext(ctx)}})
+	if err != nil {
+		return model.StoreStatus{}, fmt.Errorf("count documents: %w", err)
+	}
+	index, err := m.IndexStatus(ctx)
+	if err != nil {
+		return model.StoreStatus{}, err
+	}
+	return model.StoreStatus{Type: config.StoreMongo, Documents: count, Index: index}, nil
+}
+
+// IndexStatus reports the Atlas index state; the index is usable once
+// Atlas marks it queryable.
+func (m *mongoStore) IndexStatus(ctx context.Context) (model.IndexStatus, error) {
+	status := model.IndexStatus{Name: m.cfg.VectorIndex, Status: "MISSING"}
+	index, exists, err := findVectorIndex(ctx, m.collection, m.cfg.VectorIndex)
+	if err != nil {
+		return model.IndexStatus{}, fmt.Errorf("list vector indexes: %w", err)
+	}
+	if exists {
+		if value, ok := index.Lookup("status").StringValueOK(); ok {
+			status.Status = value
+		}
+		status.Queryable, _ = index.Lookup("queryable").BooleanOK()
+	}
+	return status, nil
+}
+
+// rescore replaces the ANN scores of the oversampled set with exact cosine
+// scores computed from the returned embeddings.
+func rescore(docs []model.Document, queryVector []float32) {
+	for i := range docs {
+		if len(docs[i].Embedding) == len(queryVector) {
+			docs[i].Score = CosineScore(queryVector, docs[i].Embedding)
+		}
+	}
+}
+
+// ownedBy matches document id only within the caller's tenant, so other
+// tenants' documents look like they do not exist.
+func ownedBy(ctx context.Context, id primitive.ObjectID) bson.D {
+	return bson.D{{Key: "_id", Value: id}, {Key: "tenant", Value: tenant.FromContext(ctx)}}
+}
+
+// tenantFilter restricts a $vectorSearch to the caller's tenant.
+func tenantFilter(ctx context.Context) bson.D {
+	return bson.D{{Key: "tenant", Value: bson.D{{Key: "$eq", Value: tenant.FromContext(ctx)}}}}
+}
+
+func documentPayload(doc model.DocumentInput, embedding []float32, owner string) bson.M {
+	payload := bson.M{
+		"content":   doc.Content,
+		"embedding": encodeFloat32Vector(embedding),
+		"tenant":    owner,
+	}
+	if len(doc.Metadata) > 0 {
+		payload["metadata"] = doc.Metadata
+	}
+	return payload
 }
 
 func float32ToFloat64(vector []float32) []float64 {
@@ -144,51 +377,161 @@ func float64ToFloat32(vector []float64) []float32 {
 	return result
 }
 
-// EnsureIndexes creates the Atlas Vector Search index when it does not exist.
+// EnsureIndexes assigns documents stored before tenants existed to
+// tenant.Default and indexes the tenant field. It then creates the Atlas
+// Vector Search index when it does not exist, and updates an existing index
+// whose dimension, quantization or tenant filter field differ from the
+// configuration. An index of another type, such as an Atlas Search index
+// from an older release, is dropped and recreated, since Atlas cannot
+// change the type of an index in place.
 func EnsureIndexes(ctx context.Context, coll *mongo.Collection, cfg config.MongoDB) error {
-	exists, err := vectorIndexExists(ctx, coll, cfg.VectorIndex)
+	_, err := coll.UpdateMany(ctx,
+		bson.D{{Key: "tenant", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "tenant", Value: tenant.Default}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("backfill document tenants: %w", err)
+	}
+	// Per-tenant counts for the status endpoint read this index.
+	if _, err := coll.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "tenant", Value: 1}}}); err != nil {
+		return fmt.Errorf("create tenant index: %w", err)
+	}
+
+	existing, exists, err := findVectorIndex(ctx, coll, cfg.VectorIndex)
 	if err != nil {
 		return fmt.Errorf("list vector indexes: %w", err)
 	}
-	if exists {
+
+	definition := vectorIndexDefinition(cfg)
+	if exists && existing.Lookup("type").StringValue() != vectorSearchType {
+		command := bson.D{
+			{Key: "dropSearchIndex", Value: coll.Name()},
+			{Key: "name", Value: cfg.VectorIndex},
+		}
+		if err := coll.Database().RunCommand(ctx, command).Err(); err != nil {
+			return fmt.Errorf("drop search index %s: %w", cfg.VectorIndex, err)
+		}
+		exists = false
+	}
+	if !exists {
+		command := bson.D{
+			{Key: "createSearchIndexes", Value: coll.Name()},
+			{Key: "indexes", Value: bson.A{
+				bson.D{
+					{Key: "name", Value: cfg.VectorIndex},
+					{Key: "type", Value: vectorSearchType},
+					{Key: "definition", Value: definition},
+				},
+			}},
+		}
+		if err := coll.Database().RunCommand(ctx, command).Err(); err != nil {
+			return fmt.Errorf("create vector index: %w", err)
+		}
 		return nil
 	}
 
+	if vectorIndexCurrent(existing, cfg) {
+		return nil
+	}
 	command := bson.D{
-		{Key: "createSearchIndexes", Value: coll.Name()},
-		{Key: "indexes", Value: bson.A{
-			bson.D{
-				{Key: "name", Value: cfg.VectorIndex},
-				{Key: "definition", Value: bson.D{
-					{Key: "mappings", Value: bson.D{
-						{Key: "dynamic", Value: false},
-						{Key: "fields", Value: bson.D{
-							{Key: "embedding", Value: bson.D{
-								{Key: "type", Value: "vector"},
-								{Key: "similarity", Value: "cosine"},
-								{Key: "numDimensions", Value: cfg.EmbeddingDimension},
-							}},
-						}},
-					}},
-				}},
-			},
-		}},
+		{Key: "updateSearchIndex", Value: coll.Name()},
+		{Key: "name", Value: cfg.VectorIndex},
+		{Key: "definition", Value: definition},
 	}
-
 	if err := coll.Database().RunCommand(ctx, command).Err(); err != nil {
-		return fmt.Errorf("create vector index: %w", err)
+		return fmt.Errorf("update vector index: %w", err)
 	}
 	return nil
 }
 
-func vectorIndexExists(ctx context.Context, coll *mongo.Collection, name string) (bool, error) {
+// vectorSearchType is the index type $vectorSearch queries, pre-filters
+// and quantization require.
+const vectorSearchType = "vectorSearch"
+
+// vectorIndexCurrent reports whether the latest definition of an existing
+// vectorSearch index already has the settings vectorIndexDefinition would
+// give it.
+func vectorIndexCurrent(existing bson.Raw, cfg config.MongoDB) bool {
+	if existing.Lookup("type").StringValue() != vectorSearchType {
+		return false
+	}
+	fields, ok := existing.Lookup("latestDefinition", "fields").ArrayOK()
+	if !ok {
+		return false
+	}
+	values, err := fields.Values()
+	if err != nil {
+		return false
+	}
+
+	want := cfg.Quantization
+	if want == config.QuantizationNone {
+		want = ""
+	}
+	var vectorCurrent, tenantFiltered bool
+	for _, value := range values {
+		field, ok := value.DocumentOK()
+		if !ok {
+			continue
+		}
+		kind, _ := field.Lookup("type").StringValueOK()
+		path, _ := field.Lookup("path").StringValueOK()
+		switch {
+		case kind == "vector" && path == "embedding":
+			dimension, _ := field.Lookup("numDimensions").AsInt64OK()
+			similarity, _ := field.Lookup("similarity").StringValueOK()
+			quantization, _ := field.Lookup("quantization").StringValueOK()
+			vectorCurrent = dimension == int64(cfg.EmbeddingDimension) && similarity == "cosine" && quantization == want
+		case kind == "filter" && path == "tenant":
+			tenantFiltered = true
+		}
+	}
+	return vectorCurrent && tenantFiltered
+}
+
+// vectorIndexDefinition indexes the embedding and declares tenant as a
+// filter field so searches can be restricted to one tenant.
+func vectorIndexDefinition(cfg config.MongoDB) bson.D {
+	vector := bson.D{
+		{Key: "type", Value: "vector"},
+		{Key: "path", Value: "embedding"},
+		{Key: "numDimensions", Value: cfg.EmbeddingDimension},
+		{Key: "similarity", Value: "cosine"},
+	}
+	if cfg.Quantization != "" && cfg.Quantization != config.QuantizationNone {
+		vector = append(vector, bson.E{Key: "quantization", Value: cfg.Quantization})
+	}
+
+	return bson.D{
+		{Key: "fields", Value: bson.A{
+			vector,
+			bson.D{{Key: "type", Value: "filter"}, {Key: "path", Value: "tenant"}},
+		}},
+	}
+}
+
+func findVectorIndex(ctx context.Context, coll *mongo.Collection, name string) (bson.Raw, bool, error) {
 	cursor, err := coll.Aggregate(ctx, mongo.Pipeline{
 		{{Key: "$listSearchIndexes", Value: bson.D{{Key: "name", Value: name}}}},
 	})
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 	defer cursor.Close(ctx)
 
-	return cursor.Next(ctx), cursor.Err()
+	if !cursor.Next(ctx) {
+		return nil, false, cursor.Err()
+	}
+	return append(bson.Raw(nil), cursor.Current...), true, nil
+}
+
+// SortByScore orders by descending score, breaking ties by id so result
+// order is stable across calls.
+func SortByScore(docs []model.Document) {
+	sort.Slice(docs, func(a, b int) bool {
+		if docs[a].Score != docs[b].Score {
+			return docs[a].Score > docs[b].Score
+		}
+		return docs[a].ID.Hex() < docs[b].ID.Hex()
+	})
 }
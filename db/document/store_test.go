@@ -0,0 +1,90 @@
+package document
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"vector-database/config"
+)
+
+func TestVectorIndexCurrent(t *testing.T) {
+	index := func(cfg config.MongoDB) bson.Raw {
+		raw, err := bson.Marshal(bson.D{
+			{Key: "name", Value: cfg.VectorIndex},
+			{Key: "type", Value: vectorSearchType},
+			{Key: "latestDefinition", Value: vectorIndexDefinition(cfg)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	base := config.MongoDB{VectorIndex: "vector_index", EmbeddingDimension: 8}
+	scalar := base
+	scalar.Quantization = config.QuantizationScalar
+	none := base
+	none.Quantization = config.QuantizationNone
+	wider := base
+	wider.EmbeddingDimension = 16
+
+	cases := []struct {
+		name     string
+		existing bson.Raw
+		cfg      config.MongoDB
+		want     bool
+	}{
+		{"unchanged", index(base), base, true},
+		{"none matches unset", index(base), none, true},
+		{"quantization added", index(base), scalar, false},
+		{"quantization removed", index(scalar), base, false},
+		{"dimension changed", index(base), wider, false},
+	}
+	for _, c := range cases {
+		if got := vectorIndexCurrent(c.existing, c.cfg); got != c.want {
+			t.Errorf("%s: vectorIndexCurrent = %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	withoutTenant, _ := bson.Marshal(bson.D{
+		{Key: "type", Value: vectorSearchType},
+		{Key: "latestDefinition", Value: bson.D{{Key: "fields", Value: bson.A{
+			bson.D{{Key: "type", Value: "vector"}, {Key: "path", Value: "embedding"}, {Key: "numDimensions", Value: 8}, {Key: "similarity", Value: "cosine"}},
+		}}}},
+	})
+	if vectorIndexCurrent(withoutTenant, base) {
+		t.Error("index without the tenant filter field reported current")
+	}
+
+	searchIndex, _ := bson.Marshal(bson.D{
+		{Key: "type", Value: "search"},
+		{Key: "latestDefinition", Value: bson.D{{Key: "mappings", Value: bson.D{{Key: "fields", Value: bson.D{
+			{Key: "embedding", Value: bson.D{{Key: "type", Value: "vector"}, {Key: "numDimensions", Value: 8}}},
+			{Key: "tenant", Value: bson.D{{Key: "type", Value: "filter"}}},
+		}}}}}},
+	})
+	if vectorIndexCurrent(searchIndex, base) {
+		t.Error("Atlas Search index with mappings reported current")
+	}
+}
+
+func TestVectorIndexDefinitionShape(t *testing.T) {
+	cfg := config.MongoDB{EmbeddingDimension: 8, Quantization: config.QuantizationBinary}
+	encoded, err := bson.Marshal(vectorIndexDefinition(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := bson.Raw(encoded)
+	vector := raw.Lookup("fields", "0").Document()
+	if vector.Lookup("type").StringValue() != "vector" || vector.Lookup("path").StringValue() != "embedding" ||
+		vector.Lookup("quantization").StringValue() != config.QuantizationBinary {
+		t.Errorf("vector field = %s", vector)
+	}
+	filter := raw.Lookup("fields", "1").Document()
+	if filter.Lookup("type").StringValue() != "filter" || filter.Lookup("path").StringValue() != "tenant" {
+		t.Errorf("filter field = %s", filter)
+	}
+	if _, err := raw.LookupErr("mappings"); err == nil {
+		t.Error("definition has Atlas Search mappings")
+	}
+}
@@ -0,0 +1,19 @@
+package document
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"vector-database/tracing"
+)
+
+// startSpan begins a span for an operation on the documents collection.
+func (m *mongoStore) startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "mongo."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.collection.name", m.collection.Name()),
+		attribute.String("db.operation.name", operation),
+	))
+}
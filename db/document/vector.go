@@ -0,0 +1,94 @@
+package document
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BSON binary subtype 9 stores dense vectors as a dtype byte, a padding byte
+// and the packed elements.
+const (
+	binarySubtypeVector byte = 0x09
+
+	vectorDTypeInt8       byte = 0x03
+	vectorDTypeFloat32    byte = 0x27
+	vectorDTypePackedBits byte = 0x10
+)
+
+// encodeFloat32Vector packs the embedding as a little-endian float32 binData
+// vector, half the size of a BSON array of doubles.
+func encodeFloat32Vector(vector []float32) primitive.Binary {
+	data := make([]byte, 2+4*len(vector))
+	data[0] = vectorDTypeFloat32
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(data[2+4*i:], math.Float32bits(v))
+	}
+	return primitive.Binary{Subtype: binarySubtypeVector, Data: data}
+}
+
+// decodeEmbedding accepts both the binData vector format and the legacy
+// array of doubles so collections written before the switch stay readable.
+// Quantized int8 and packed-bit vectors are widened back to float32.
+func decodeEmbedding(raw bson.RawValue) ([]float32, error) {
+	switch raw.Type {
+	case 0, bsontype.Null:
+		return nil, nil
+	case bsontype.Array:
+		var values []float64
+		if err := raw.Unmarshal(&values); err != nil {
+			return nil, fmt.Errorf("decode embedding array: %w", err)
+		}
+		return float64ToFloat32(values), nil
+	case bsontype.Binary:
+		subtype, data := raw.Binary()
+		if subtype != binarySubtypeVector {
+			return nil, fmt.Errorf("unexpected embedding binary subtype 0x%02x", subtype)
+		}
+		return decodeVectorData(data)
+	default:
+		return nil, fmt.Errorf("unexpected embedding type %s", raw.Type)
+	}
+}
+
+func decodeVectorData(data []byte) ([]float32, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("embedding vector header truncated")
+	}
+	dtype, padding, payload := data[0], data[1], data[2:]
+
+	switch dtype {
+	case vectorDTypeFloat32:
+		if len(payload)%4 != 0 {
+			return nil, fmt.Errorf("float32 embedding vector has %d trailing bytes", len(payload)%4)
+		}
+		vector := make([]float32, len(payload)/4)
+		for i := range vector {
+			vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(payload[4*i:]))
+		}
+		return vector, nil
+	case vectorDTypeInt8:
+		vector := make([]float32, len(payload))
+		for i, b := range payload {
+			vector[i] = float32(int8(b))
+		}
+		return vector, nil
+	case vectorDTypePackedBits:
+		if padding > 7 || (len(payload) == 0 && padding != 0) {
+			return nil, fmt.Errorf("invalid packed bit vector padding %d", padding)
+		}
+		vector := make([]float32, len(payload)*8-int(padding))
+		for i := range vector {
+			if payload[i/8]&(0x80>>(i%8)) != 0 {
+				vector[i] = 1
+			}
+		}
+		return vector, nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding vector dtype 0x%02x", dtype)
+	}
+}
@@ -0,0 +1,197 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	walFile         = "wal.log"
+	walHeaderSize   = 8
+	maxWALRecordLen = 64 << 20
+)
+
+var walChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTornWALRecord marks a record cut short by the end of the log.
+var errTornWALRecord = errors.New("torn wal record")
+
+func init() {
+	// Metadata decoded from JSON nests these types inside interface values,
+	// which gob can only encode once they are registered.
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+type walOp string
+
+const (
+	walInsert walOp = "insert"
+	walUpdate walOp = "update"
+	walDelete walOp = "delete"
+)
+
+// walRecord is one logged mutation. Records are framed as a little-endian
+// uint32 payload length, a CRC-32C of the payload, then the gob payload.
+// Gob keeps the concrete types of metadata values, so an int is still an
+// int after recovery.
+type walRecord struct {
+	Seq       uint64
+	Op        walOp
+	ID        primitive.ObjectID
+	Content   string
+	Metadata  map[string]interface{}
+	Embedding []float32
+	Tenant    string
+}
+
+type wal struct {
+	file *os.File
+}
+
+func openWAL(path string) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	return &wal{file: file}, nil
+}
+
+// append writes the records and syncs them to disk before returning, so a
+// mutation is durable once it is acknowledged. A failed write is cut off
+// again, so the log never holds a record whose mutation was rejected.
+func (w *wal) append(records ...walRecord) error {
+	var buf []byte
+	for _, record := range records {
+		var payload bytes.Buffer
+		if err := gob.NewEncoder(&payload).Encode(record); err != nil {
+			return fmt.Errorf("encode wal record: %w", err)
+		}
+		header := make([]byte, walHeaderSize)
+		binary.LittleEndian.PutUint32(header[0:4], uint32(payload.Len()))
+		binary.LittleEndian.PutUint32(header[4:8], crc32.Checksum(payload.Bytes(), walChecksumTable))
+		buf = append(buf, header...)
+		buf = append(buf, payload.Bytes()...)
+	}
+
+	offset, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("seek wal: %w", err)
+	}
+	_, err = w.file.Write(buf)
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if err != nil {
+		if truncErr := w.truncate(offset); truncErr != nil {
+			return fmt.Errorf("write wal: %w (rollback: %v)", err, truncErr)
+		}
+		return fmt.Errorf("write wal: %w", err)
+	}
+	return nil
+}
+
+// replay calls apply for every record in order. A crash mid-write can only
+// damage the last record, so a torn final record is cut off and new records
+// follow valid data. Damage anywhere before it is reported, since skipping
+// it would silently drop every later mutation.
+func (w *wal) replay(apply func(walRecord) error) error {
+	info, err := w.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat wal: %w", err)
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek wal: %w", err)
+	}
+
+	reader := bufio.NewReader(w.file)
+	size := info.Size()
+	var offset int64
+	for offset < size {
+		record, length, err := readWALRecord(reader, size-offset)
+		if errors.Is(err, errTornWALRecord) || (err != nil && offset+length == size) {
+			if err := w.truncate(offset); err != nil {
+				return fmt.Errorf("truncate torn wal record: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("wal corrupt at offset %d: %w", offset, err)
+		}
+		if err := apply(record); err != nil {
+			return fmt.Errorf("apply wal record %d: %w", record.Seq, err)
+		}
+		offset += length
+	}
+
+	if _, err := w.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek wal: %w", err)
+	}
+	return nil
+}
+
+// readWALRecord reads the next record, given the bytes left in the log. It
+// returns the record's framed length even when the payload is invalid, and
+// errTornWALRecord when the record runs past the end of the log.
+func readWALRecord(reader io.Reader, remaining int64) (walRecord, int64, error) {
+	if remaining < walHeaderSize {
+		return walRecord{}, remaining, errTornWALRecord
+	}
+	header := make([]byte, walHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return walRecord{}, 0, fmt.Errorf("read wal header: %w", err)
+	}
+
+	length := int64(binary.LittleEndian.Uint32(header[0:4]))
+	if walHeaderSize+length > remaining {
+		return walRecord{}, remaining, errTornWALRecord
+	}
+	if length > maxWALRecordLen {
+		return walRecord{}, walHeaderSize + length, fmt.Errorf("wal record length %d exceeds limit", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return walRecord{}, 0, fmt.Errorf("read wal record: %w", err)
+	}
+	if crc32.Checksum(payload, walChecksumTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return walRecord{}, walHeaderSize + length, errors.New("wal record checksum mismatch")
+	}
+
+	var record walRecord
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&record); err != nil {
+		return walRecord{}, walHeaderSize + length, fmt.Errorf("decode wal record: %w", err)
+	}
+	return record, walHeaderSize + length, nil
+}
+
+// reset discards every record once a snapshot covers them.
+func (w *wal) reset() error {
+	if err := w.truncate(0); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	return nil
+}
+
+// truncate cuts the log at offset and positions new writes there.
+func (w *wal) truncate(offset int64) error {
+	if err := w.file.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+func (w *wal) close() error {
+	return w.file.Close()
+}
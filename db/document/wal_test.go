@@ -0,0 +1,205 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/config"
+	"vector-database/model"
+)
+
+func writeTestWAL(t *testing.T, records ...walRecord) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), walFile)
+	w, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.append(records...); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func replayTestWAL(t *testing.T, path string) ([]uint64, error) {
+	t.Helper()
+	w, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+
+	var seqs []uint64
+	err = w.replay(func(record walRecord) error {
+		seqs = append(seqs, record.Seq)
+		return nil
+	})
+	return seqs, err
+}
+
+func testRecords(n int) []walRecord {
+	records := make([]walRecord, n)
+	for i := range records {
+		records[i] = walRecord{
+			Seq:       uint64(i + 1),
+			Op:        walInsert,
+			ID:        primitive.NewObjectID(),
+			Content:   "message",
+			Embedding: []float32{1, 0},
+		}
+	}
+	return records
+}
+
+func TestWALReplayTruncatesTornFinalRecord(t *testing.T) {
+	path := writeTestWAL(t, testRecords(3)...)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-5); err != nil {
+		t.Fatal(err)
+	}
+
+	seqs, err := replayTestWAL(t, path)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !reflect.DeepEqual(seqs, []uint64{1, 2}) {
+		t.Fatalf("replayed %v, want [1 2]", seqs)
+	}
+
+	w, err := openWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.replay(func(walRecord) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.append(walRecord{Seq: 3, Op: walDelete}); err != nil {
+		t.Fatal(err)
+	}
+	w.close()
+
+	seqs, err = replayTestWAL(t, path)
+	if err != nil {
+		t.Fatalf("replay after append: %v", err)
+	}
+	if !reflect.DeepEqual(seqs, []uint64{1, 2, 3}) {
+		t.Errorf("replayed %v after append, want [1 2 3]", seqs)
+	}
+}
+
+func TestWALReplayTruncatesCorruptFinalRecord(t *testing.T) {
+	path := writeTestWAL(t, testRecords(2)...)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	seqs, err := replayTestWAL(t, path)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !reflect.DeepEqual(seqs, []uint64{1}) {
+		t.Errorf("replayed %v, want [1]", seqs)
+	}
+}
+
+func TestWALReplayFailsOnCorruptionBeforeTheEnd(t *testing.T) {
+	path := writeTestWAL(t, testRecords(3)...)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[walHeaderSize+1] ^= 0xff
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := replayTestWAL(t, path); err == nil {
+		t.Fatal("replay accepted a corrupt record before the end of the log")
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(data) {
+		t.Errorf("replay changed the log from %d to %d bytes", len(data), len(after))
+	}
+}
+
+func TestMemoryStoreRecoversMetadataTypes(t *testing.T) {
+	metadata := map[string]interface{}{
+		"count":  3,
+		"ratio":  0.5,
+		"tags":   []interface{}{"a", 2},
+		"nested": map[string]interface{}{"ok": true},
+	}
+	cfg := config.MongoDB{EmbeddingDimension: 2}
+
+	for _, snapshot := range []bool{false, true} {
+		storeCfg := config.Store{Path: t.TempDir()}
+		store, err := NewMemoryStore(cfg, storeCfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := store.InsertDocument(context.Background(), model.DocumentInput{Content: "hello", Metadata: metadata}, []float32{1, 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snapshot {
+			if err := store.(*memoryStore).Snapshot(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Drop the store without a final snapshot, as a crash would.
+		store.(*memoryStore).wal.close()
+
+		recovered, err := NewMemoryStore(cfg, storeCfg)
+		if err != nil {
+			t.Fatalf("recover (snapshot=%v): %v", snapshot, err)
+		}
+		got, err := recovered.GetDocuments(context.Background(), []primitive.ObjectID{doc.ID})
+		if err != nil || len(got) != 1 {
+			t.Fatalf("recover (snapshot=%v): %v, %v", snapshot, got, err)
+		}
+		if !reflect.DeepEqual(got[0].Metadata, metadata) {
+			t.Errorf("recovered metadata (snapshot=%v) = %#v, want %#v", snapshot, got[0].Metadata, metadata)
+		}
+		recovered.(*memoryStore).wal.close()
+	}
+}
+
+func TestMemoryStoreLogsOnlyAcceptedMutations(t *testing.T) {
+	storeCfg := config.Store{Path: t.TempDir()}
+	store, err := NewMemoryStore(config.MongoDB{EmbeddingDimension: 2}, storeCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := store.(*memoryStore)
+	defer m.wal.close()
+
+	if err := m.commit(walRecord{Op: walInsert, ID: primitive.NewObjectID(), Embedding: []float32{1}}); err == nil {
+		t.Fatal("commit accepted an embedding of the wrong dimension")
+	}
+	info, err := os.Stat(m.path(walFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 0 || m.seq != 0 {
+		t.Errorf("rejected mutation left %d wal bytes and seq %d", info.Size(), m.seq)
+	}
+}
@@ -0,0 +1,56 @@
+package embeddingcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store persists embeddings keyed by content hash and encoder identity.
+type Store interface {
+	Get(ctx context.Context, key string) ([]float32, bool, error)
+	Put(ctx context.Context, key string, embedding []float32) error
+}
+
+type mongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewStore wires the Mongo collection into an embedding cache Store.
+func NewStore(collection *mongo.Collection) Store {
+	return &mongoStore{collection: collection}
+}
+
+func (m *mongoStore) Get(ctx context.Context, key string) ([]float32, bool, error) {
+	var entry struct {
+		Embedding []float32 `bson:"embedding"`
+	}
+	err := m.collection.FindOne(ctx, bson.D{{Key: "_id", Value: key}}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("find cached embedding: %w", err)
+	}
+	return entry.Embedding, true, nil
+}
+
+func (m *mongoStore) Put(ctx context.Context, key string, embedding []float32) error {
+	_, err := m.collection.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: key}},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "embedding", Value: embedding},
+			{Key: "createdAt", Value: time.Now().UTC()},
+		}}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("store cached embedding: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store counts requests per quota window.
+type Store interface {
+	// Increment adds one to the counter for key and returns the new count.
+	// The counter may be discarded after expireAt.
+	Increment(ctx context.Context, key string, expireAt time.Time) (int64, error)
+}
+
+type mongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewStore wires the Mongo collection into a quota Store.
+func NewStore(collection *mongo.Collection) Store {
+	return &mongoStore{collection: collection}
+}
+
+// EnsureIndexes lets Mongo delete counters of past windows.
+func EnsureIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expireAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("create quota expiry index: %w", err)
+	}
+	return nil
+}
+
+func (m *mongoStore) Increment(ctx context.Context, key string, expireAt time.Time) (int64, error) {
+	var counter struct {
+		Count int64 `bson:"count"`
+	}
+	err := m.collection.FindOneAndUpdate(ctx,
+		bson.D{{Key: "_id", Value: key}},
+		bson.D{
+			{Key: "$inc", Value: bson.D{{Key: "count", Value: 1}}},
+			{Key: "$setOnInsert", Value: bson.D{{Key: "expireAt", Value: expireAt}}},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("increment quota counter: %w", err)
+	}
+	return counter.Count, nil
+}
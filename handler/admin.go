@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"vector-database/httpinfo"
+	"vector-database/model"
+	"vector-database/service"
+)
+
+// AdminHandler exposes operational endpoints.
+type AdminHandler struct {
+	service service.SearchService
+}
+
+// NewAdminHandler wires the provided SearchService into admin HTTP routes.
+func NewAdminHandler(svc service.SearchService) *AdminHandler {
+	return &AdminHandler{service: svc}
+}
+
+// Routes binds the admin endpoints.
+func (h *AdminHandler) Routes() []Route {
+	return []Route{
+		{Endpoint: httpinfo.TrainIndexEndpoint, Handle: h.handleTrainIndex},
+	}
+}
+
+func (h *AdminHandler) handleTrainIndex(w http.ResponseWriter, r *http.Request) {
+	info, err := h.service.TrainIndex(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, trainIndexResponse{Index: info})
+}
+
+type trainIndexResponse struct {
+	Index model.IndexInfo `json:"index"`
+}
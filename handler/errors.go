@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"vector-database/auth"
+	"vector-database/httpinfo"
+	"vector-database/logging"
+	"vector-database/service"
+)
+
+// detailer is implemented by errors that carry machine-readable details,
+// such as service.DimensionError.
+type detailer interface {
+	Details() map[string]interface{}
+}
+
+// writeError responds with a message the handler wrote itself, under the
+// code its status implies.
+func writeError(w http.ResponseWriter, r *http.Request, status int, msg string) {
+	httpinfo.WriteError(w, r, status, httpinfo.APIError{Message: msg})
+}
+
+// writeServiceError maps an error returned by a service to its status and
+// code. Internal and upstream failures are logged and their message
+// replaced, so store and upstream internals do not reach clients.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := errorStatus(err)
+	body := httpinfo.APIError{Code: code, Message: err.Error()}
+
+	var detailed detailer
+	if errors.As(err, &detailed) {
+		body.Details = detailed.Details()
+	}
+	if code == httpinfo.CodeInternal || code == httpinfo.CodeUnavailable {
+		logging.FromContext(r.Context()).Error("request failed", "error", err, "code", code)
+		body.Message = http.StatusText(status)
+	}
+	if seconds, ok := body.Details["retry_after_seconds"].(int); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	httpinfo.WriteError(w, r, status, body)
+}
+
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrDimensionMismatch):
+		return http.StatusBadRequest, httpinfo.CodeDimensionMismatch
+	case errors.Is(err, service.ErrInvalidArgument), errors.Is(err, auth.ErrInvalidArgument):
+		return http.StatusBadRequest, httpinfo.CodeInvalidArgument
+	case errors.Is(err, service.ErrFailedPrecondition):
+		return http.StatusConflict, httpinfo.CodeFailedPrecondition
+	case errors.Is(err, service.ErrNotFound), errors.Is(err, auth.ErrNotFound):
+		return http.StatusNotFound, httpinfo.CodeNotFound
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict, httpinfo.CodeConflict
+	case errors.Is(err, auth.ErrForbidden):
+		return http.StatusForbidden, httpinfo.CodeForbidden
+	case errors.Is(err, auth.ErrNoKeyStore):
+		return http.StatusNotImplemented, httpinfo.CodeNotImplemented
+	case errors.Is(err, service.ErrUnavailable):
+		return http.StatusServiceUnavailable, httpinfo.CodeUnavailable
+	default:
+		return http.StatusInternalServerError, httpinfo.CodeInternal
+	}
+}
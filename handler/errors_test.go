@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"vector-database/auth"
+	"vector-database/httpinfo"
+	"vector-database/service"
+)
+
+func TestErrorStatus(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{&service.DimensionError{Expected: 3, Got: 2}, http.StatusBadRequest, httpinfo.CodeDimensionMismatch},
+		{fmt.Errorf("%w: bad limit", service.ErrInvalidArgument), http.StatusBadRequest, httpinfo.CodeInvalidArgument},
+		{auth.ErrInvalidArgument, http.StatusBadRequest, httpinfo.CodeInvalidArgument},
+		{fmt.Errorf("%w: empty store", service.ErrFailedPrecondition), http.StatusConflict, httpinfo.CodeFailedPrecondition},
+		{fmt.Errorf("%w: document x", service.ErrNotFound), http.StatusNotFound, httpinfo.CodeNotFound},
+		{auth.ErrNotFound, http.StatusNotFound, httpinfo.CodeNotFound},
+		{fmt.Errorf("%w: duplicate key", service.ErrConflict), http.StatusConflict, httpinfo.CodeConflict},
+		{auth.ErrForbidden, http.StatusForbidden, httpinfo.CodeForbidden},
+		{auth.ErrNoKeyStore, http.StatusNotImplemented, httpinfo.CodeNotImplemented},
+		{fmt.Errorf("%w: reranker", service.ErrUnavailable), http.StatusServiceUnavailable, httpinfo.CodeUnavailable},
+		{errors.New("document x has a 2-dimensional embedding, expected 3"), http.StatusInternalServerError, httpinfo.CodeInternal},
+	}
+	for _, tc := range cases {
+		status, code := errorStatus(tc.err)
+		if status != tc.status || code != tc.code {
+			t.Errorf("errorStatus(%v) = %d %s, want %d %s", tc.err, status, code, tc.status, tc.code)
+		}
+	}
+}
+
+func TestWriteServiceErrorHidesInternalMessages(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/messages", nil)
+	writeServiceError(rec, req, errors.New("mongo: connection string secret"))
+
+	body := decodeError(t, rec)
+	if rec.Code != http.StatusInternalServerError || body.Code != httpinfo.CodeInternal {
+		t.Errorf("got %d %s", rec.Code, body.Code)
+	}
+	if body.Message != http.StatusText(http.StatusInternalServerError) {
+		t.Errorf("message = %q, want the generic status text", body.Message)
+	}
+}
+
+func TestUnmatchedWritesJSONErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, Route{Endpoint: httpinfo.HealthEndpoint, Handle: func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}})
+	mux.HandleFunc("POST /only-post", func(http.ResponseWriter, *http.Request) {})
+	server := Unmatched(mux)
+
+	cases := []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{http.MethodGet, "/nowhere", http.StatusNotFound, httpinfo.CodeNotFound},
+		{http.MethodGet, "/only-post", http.StatusMethodNotAllowed, httpinfo.CodeMethodNotAllowed},
+		{http.MethodPost, httpinfo.HealthEndpoint.Path, http.StatusMethodNotAllowed, httpinfo.CodeMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: status %d, want %d", tc.method, tc.path, rec.Code, tc.status)
+			continue
+		}
+		if body := decodeError(t, rec); body.Code != tc.code {
+			t.Errorf("%s %s: code %q, want %q", tc.method, tc.path, body.Code, tc.code)
+		}
+		if tc.status == http.StatusMethodNotAllowed && rec.Header().Get("Allow") == "" {
+			t.Errorf("%s %s: no Allow header", tc.method, tc.path)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, httpinfo.HealthEndpoint.Path, nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("matched route: status %d, want 200", rec.Code)
+	}
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) httpinfo.APIError {
+	t.Helper()
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	var body struct {
+		Error httpinfo.APIError `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode error body: %v", err)
+	}
+	return body.Error
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"vector-database/model"
+)
+
+type messageGroupResponse struct {
+	Value   interface{}               `json:"value"`
+	Count   int                       `json:"count"`
+	Results []messageDocumentResponse `json:"results"`
+}
+
+type facetResponse struct {
+	Value interface{} `json:"value"`
+	Count int         `json:"count"`
+}
+
+type groupedMessageResponse struct {
+	Query   string                 `json:"query"`
+	GroupBy string                 `json:"group_by"`
+	Groups  []messageGroupResponse `json:"groups"`
+	Facets  []facetResponse        `json:"facets"`
+}
+
+// handleGroupedMessages serves GET /api/messages with group_by: limit is the
+// number of groups and group_size the results kept per group.
+func (h *MessageHandler) handleGroupedMessages(w http.ResponseWriter, r *http.Request, text, field string, opts model.SearchOptions) {
+	perGroup := model.DefaultGroupSize
+	if raw := r.URL.Query().Get("group_size"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			writeError(w, r, http.StatusBadRequest, "group_size must be a positive integer")
+			return
+		}
+		perGroup = value
+	}
+
+	res, err := h.service.GroupByText(r.Context(), model.GroupQuery{
+		Text:     text,
+		Field:    field,
+		PerGroup: perGroup,
+		Options:  opts,
+	})
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := groupedMessageResponse{
+		Query:   text,
+		GroupBy: field,
+		Groups:  make([]messageGroupResponse, len(res.Groups)),
+		Facets:  make([]facetResponse, len(res.Facets)),
+	}
+	for i, group := range res.Groups {
+		resp.Groups[i] = messageGroupResponse{
+			Value:   group.Value,
+			Count:   group.Count,
+			Results: toMessageResponses(group.Documents),
+		}
+	}
+	for i, facet := range res.Facets {
+		resp.Facets[i] = facetResponse{Value: facet.Value, Count: facet.Count}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
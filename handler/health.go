@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"vector-database/httpinfo"
+	"vector-database/service"
+)
+
+// readinessTimeout bounds all readiness checks so a hung dependency fails
+// the probe instead of stalling it.
+const readinessTimeout = 5 * time.Second
+
+// HealthHandler serves the probe and status endpoints.
+type HealthHandler struct {
+	service service.HealthService
+}
+
+// NewHealthHandler wires the HealthService into HTTP routes.
+func NewHealthHandler(svc service.HealthService) *HealthHandler {
+	return &HealthHandler{service: svc}
+}
+
+// ProbeRoutes binds /healthz and /readyz, which should be mounted outside
+// authentication.
+func (h *HealthHandler) ProbeRoutes() []Route {
+	return []Route{
+		{Endpoint: httpinfo.HealthEndpoint, Handle: h.handleHealth},
+		{Endpoint: httpinfo.ReadyEndpoint, Handle: h.handleReady},
+	}
+}
+
+// Routes binds the admin status endpoint.
+func (h *HealthHandler) Routes() []Route {
+	return []Route{
+		{Endpoint: httpinfo.StatusEndpoint, Handle: h.handleStatus},
+	}
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+func (h *HealthHandler) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+func (h *HealthHandler) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	readiness := h.service.Ready(ctx)
+	status := http.StatusOK
+	if !readiness.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, readiness)
+}
+
+func (h *HealthHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.Status(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
@@ -29,34 +29,31 @@ func NewImageHandler(svc service.SearchService) *ImageHandler {
 	return &ImageHandler{service: svc}
 }
 
-// Register attaches the image HTTP endpoints to the mux.
-func (h *ImageHandler) Register(mux *http.ServeMux) {
-	mux.Handle(httpinfo.InsertImageEndpoint.Path, http.HandlerFunc(h.handleInsertImage))
-	mux.Handle(httpinfo.SearchImageEndpoint.Path, http.HandlerFunc(h.handleSearchImage))
+// Routes binds the image endpoints.
+func (h *ImageHandler) Routes() []Route {
+	return []Route{
+		{Endpoint: httpinfo.InsertImageEndpoint, Handle: h.handleInsertImage},
+		{Endpoint: httpinfo.SearchImageEndpoint, Handle: h.handleSearchImage},
+		{Endpoint: httpinfo.SimilarImagesEndpoint, Handle: h.handleSimilarImages},
+	}
 }
 
 func (h *ImageHandler) handleInsertImage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != httpinfo.InsertImageEndpoint.Method {
-		w.Header().Set("Allow", httpinfo.InsertImageEndpoint.Method)
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
-	}
-
 	if err := r.ParseMultipartForm(maxImageUploadBytes); err != nil {
-		writeError(w, http.StatusBadRequest, "multipart form required")
+		writeError(w, r, http.StatusBadRequest, "multipart form required")
 		return
 	}
 	defer cleanupMultipart(r)
 
 	imageBytes, err := readImageField(r, imageFormField)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	metadata, err := parseMetadataField(r.FormValue("metadata"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -68,66 +65,92 @@ func (h *ImageHandler) handleInsertImage(w http.ResponseWriter, r *http.Request)
 
 	doc, err := h.service.InsertImage(r.Context(), input)
 	if err != nil {
-		writeError(w, statusFromError(err), err.Error())
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]interface{}{
-		"image": doc,
-	})
+	writeJSON(w, http.StatusCreated, insertImageResponse{Image: doc})
+}
+
+type insertImageResponse struct {
+	Image model.ImageDocument `json:"image"`
 }
 
 type searchImageResponse struct {
-	Results []model.ImageDocument `json:"results"`
+	Results    []model.ImageDocument `json:"results"`
+	NextCursor string                `json:"next_cursor,omitempty"`
 }
 
 func (h *ImageHandler) handleSearchImage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != httpinfo.SearchImageEndpoint.Method {
-		w.Header().Set("Allow", httpinfo.SearchImageEndpoint.Method)
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
-		return
-	}
-
 	if err := r.ParseMultipartForm(maxImageUploadBytes); err != nil {
-		writeError(w, http.StatusBadRequest, "multipart form required")
+		writeError(w, r, http.StatusBadRequest, "multipart form required")
 		return
 	}
 	defer cleanupMultipart(r)
 
 	imageBytes, err := readImageField(r, imageFormField)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	limit, err := parseLimitField(r.FormValue("limit"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+
+	opts, err := parseSearchOptions(r.FormValue, limit)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	query := model.ImageQuery{
 		ImageData:   imageBytes,
 		Description: r.FormValue("description"),
-		Limit:       limit,
+		Options:     opts,
 	}
 
 	results, err := h.service.SearchImages(r.Context(), query)
 	if err != nil {
-		writeError(w, statusFromError(err), err.Error())
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, searchImageResponse{
-		Results: results,
-	})
+	resp := searchImageResponse{Results: results}
+	if len(results) > 0 {
+		last := results[len(results)-1]
+		resp.NextCursor = opts.NextCursor(len(results), last.Score, last.ID)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type similarImagesResponse struct {
+	ID      string                `json:"id"`
+	Results []model.ImageDocument `json:"results"`
 }
 
-func statusFromError(err error) int {
-	if errors.Is(err, service.ErrInvalidArgument) {
-		return http.StatusBadRequest
+func (h *ImageHandler) handleSimilarImages(w http.ResponseWriter, r *http.Request) {
+	query, err := parseSimilarQuery(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
-	return http.StatusInternalServerError
+
+	results, err := h.service.SimilarImages(r.Context(), query)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, similarImagesResponse{
+		ID:      query.ID,
+		Results: results,
+	})
 }
 
 func readImageField(r *http.Request, field string) ([]byte, error) {
@@ -175,6 +198,80 @@ func parseLimitField(raw string) (int, error) {
 	return value, nil
 }
 
+// parseSearchOptions reads the paging and scoring parameters shared by the
+// search endpoints from a query string or form.
+func parseSearchOptions(get func(string) string, limit int) (model.SearchOptions, error) {
+	opts := model.SearchOptions{Limit: limit}
+
+	var err error
+	if opts.Exact, err = parseBoolField("exact", get("exact")); err != nil {
+		return opts, err
+	}
+
+	if raw := strings.TrimSpace(get("min_score")); raw != "" {
+		opts.MinScore, err = strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return opts, errors.New("min_score must be a number")
+		}
+	}
+
+	if raw := strings.TrimSpace(get("offset")); raw != "" {
+		opts.Offset, err = strconv.Atoi(raw)
+		if err != nil {
+			return opts, errors.New("offset must be a non-negative integer")
+		}
+	}
+
+	if opts.Rerank, err = parseBoolField("rerank", get("rerank")); err != nil {
+		return opts, err
+	}
+	if opts.MMR, err = parseBoolField("mmr", get("mmr")); err != nil {
+		return opts, err
+	}
+	if raw := strings.TrimSpace(get("lambda")); raw != "" {
+		lambda, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return opts, errors.New("lambda must be a number between 0 and 1")
+		}
+		opts.MMRLambda = &lambda
+	}
+
+	return checkSearchOptions(opts, strings.TrimSpace(get("cursor")))
+}
+
+// checkSearchOptions validates options however they were read and resolves
+// the cursor token onto them.
+func checkSearchOptions(opts model.SearchOptions, cursor string) (model.SearchOptions, error) {
+	if opts.Offset < 0 {
+		return opts, errors.New("offset must be a non-negative integer")
+	}
+	if opts.MMRLambda != nil && (*opts.MMRLambda < 0 || *opts.MMRLambda > 1) {
+		return opts, errors.New("lambda must be a number between 0 and 1")
+	}
+	if cursor != "" {
+		if opts.Offset > 0 {
+			return opts, errors.New("offset and cursor cannot be combined")
+		}
+		var err error
+		if opts.Cursor, err = model.ParseCursor(cursor); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func parseBoolField(name, raw string) (bool, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", name)
+	}
+	return value, nil
+}
+
 func cleanupMultipart(r *http.Request) {
 	if r.MultipartForm != nil {
 		_ = r.MultipartForm.RemoveAll()
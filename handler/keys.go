@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"vector-database/auth"
+	"vector-database/httpinfo"
+	"vector-database/model"
+)
+
+// KeyHandler exposes API key management.
+type KeyHandler struct {
+	keyring auth.Keyring
+}
+
+// NewKeyHandler wires the keyring into the admin key routes.
+func NewKeyHandler(keyring auth.Keyring) *KeyHandler {
+	return &KeyHandler{keyring: keyring}
+}
+
+// Routes binds the key management endpoints.
+func (h *KeyHandler) Routes() []Route {
+	return []Route{
+		{Endpoint: httpinfo.CreateKeyEndpoint, Handle: h.handleCreateKey},
+		{Endpoint: httpinfo.ListKeysEndpoint, Handle: h.handleListKeys},
+		{Endpoint: httpinfo.DeleteKeyEndpoint, Handle: h.handleDeleteKey},
+	}
+}
+
+type createKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	Tenant string   `json:"tenant"`
+}
+
+type createKeyResponse struct {
+	Key    model.APIKey `json:"key"`
+	Secret string       `json:"secret"`
+}
+
+func (h *KeyHandler) handleCreateKey(w http.ResponseWriter, r *http.Request) {
+	var req createKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	key, secret, err := h.keyring.Create(r.Context(), req.Name, req.Scopes, req.Tenant)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, createKeyResponse{Key: key, Secret: secret})
+}
+
+func (h *KeyHandler) handleListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keyring.List(r.Context())
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, listKeysResponse{Keys: keys})
+}
+
+type listKeysResponse struct {
+	Keys []model.APIKey `json:"keys"`
+}
+
+func (h *KeyHandler) handleDeleteKey(w http.ResponseWriter, r *http.Request) {
+	if err := h.keyring.Revoke(r.Context(), r.PathValue("id")); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,8 +3,10 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -23,9 +25,15 @@ func NewMessageHandler(svc service.SearchService) *MessageHandler {
 	return &MessageHandler{service: svc}
 }
 
-// Register attaches the handler methods to the provided mux.
-func (h *MessageHandler) Register(mux *http.ServeMux) {
-	mux.Handle(httpinfo.InsertMessageEndpoint.Path, http.HandlerFunc(h.dispatchMessages))
+// Routes binds the message endpoints.
+func (h *MessageHandler) Routes() []Route {
+	return []Route{
+		{Endpoint: httpinfo.InsertMessageEndpoint, Handle: h.handleInsertMessage},
+		{Endpoint: httpinfo.GetMessageEndpoint, Handle: h.handleGetMessage},
+		{Endpoint: httpinfo.InsertMessagesEndpoint, Handle: h.handleInsertMessages},
+		{Endpoint: httpinfo.SearchMessagesEndpoint, Handle: h.handleSearchMessages},
+		{Endpoint: httpinfo.SimilarMessagesEndpoint, Handle: h.handleSimilarMessages},
+	}
 }
 
 type insertMessageRequest struct {
@@ -36,7 +44,7 @@ type insertMessageRequest struct {
 func (h *MessageHandler) handleInsertMessage(w http.ResponseWriter, r *http.Request) {
 	var req insertMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid json payload")
+		writeError(w, r, http.StatusBadRequest, "invalid json payload")
 		return
 	}
 
@@ -47,48 +55,165 @@ func (h *MessageHandler) handleInsertMessage(w http.ResponseWriter, r *http.Requ
 
 	doc, err := h.service.IndexDocument(r.Context(), docInput)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]interface{}{
-		"document": toMessageResponse(doc),
-	})
+	writeJSON(w, http.StatusCreated, insertMessageResponse{Document: toMessageResponse(doc)})
+}
+
+type insertMessageResponse struct {
+	Document messageDocumentResponse `json:"document"`
+}
+
+const maxBatchMessages = 1000
+
+type insertMessagesRequest struct {
+	Messages []insertMessageRequest `json:"messages"`
+}
+
+func (h *MessageHandler) handleInsertMessages(w http.ResponseWriter, r *http.Request) {
+	var req insertMessagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+	if len(req.Messages) > maxBatchMessages {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("at most %d messages can be inserted at once", maxBatchMessages))
+		return
+	}
+
+	inputs := make([]model.DocumentInput, len(req.Messages))
+	for i, msg := range req.Messages {
+		inputs[i] = model.DocumentInput{
+			Content:  msg.Content,
+			Metadata: msg.Metadata,
+		}
+	}
+
+	docs, err := h.service.IndexDocuments(r.Context(), inputs)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, insertMessagesResponse{Documents: toMessageResponses(docs)})
+}
+
+type insertMessagesResponse struct {
+	Documents []messageDocumentResponse `json:"documents"`
 }
 
 type getMessageResponse struct {
-	Query   string                    `json:"query"`
-	Results []messageDocumentResponse `json:"results"`
+	Query      string                    `json:"query"`
+	Results    []messageDocumentResponse `json:"results"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
 }
 
 func (h *MessageHandler) handleGetMessage(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
-		writeError(w, http.StatusBadRequest, "query parameter 'q' is required")
+		writeError(w, r, http.StatusBadRequest, "query parameter 'q' is required")
 		return
 	}
 
 	limit, err := parseLimit(r.URL.Query().Get("limit"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts, err := parseSearchOptions(r.URL.Query().Get, limit)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if field := r.URL.Query().Get("group_by"); field != "" {
+		h.handleGroupedMessages(w, r, query, field, opts)
 		return
 	}
 
-	res, err := h.service.SearchByText(r.Context(), query, limit)
+	res, err := h.service.SearchByText(r.Context(), query, opts)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err.Error())
+		writeServiceError(w, r, err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, getMessageResponse{
+	resp := getMessageResponse{
 		Query:   query,
 		Results: toMessageResponses(res),
+	}
+	if len(res) > 0 {
+		last := res[len(res)-1]
+		resp.NextCursor = opts.NextCursor(len(res), last.Score, last.ID.Hex())
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+const defaultSearchLimit = 5
+
+type similarMessagesResponse struct {
+	ID      string                    `json:"id"`
+	Results []messageDocumentResponse `json:"results"`
+}
+
+func (h *MessageHandler) handleSimilarMessages(w http.ResponseWriter, r *http.Request) {
+	query, err := parseSimilarQuery(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.SimilarDocuments(r.Context(), query)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, similarMessagesResponse{
+		ID:      query.ID,
+		Results: toMessageResponses(res),
 	})
 }
 
+// parseSimilarQuery reads the path id, the search options and the
+// positive/negative example ids, given as repeated or comma separated
+// parameters.
+func parseSimilarQuery(r *http.Request) (model.SimilarQuery, error) {
+	params := r.URL.Query()
+	limit, err := parseLimit(params.Get("limit"))
+	if err != nil {
+		return model.SimilarQuery{}, err
+	}
+	opts, err := parseSearchOptions(params.Get, limit)
+	if err != nil {
+		return model.SimilarQuery{}, err
+	}
+
+	return model.SimilarQuery{
+		ID:       r.PathValue("id"),
+		Positive: splitIDs(params["positive"]),
+		Negative: splitIDs(params["negative"]),
+		Options:  opts,
+	}, nil
+}
+
+func splitIDs(values []string) []string {
+	var ids []string
+	for _, value := range values {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
 func parseLimit(raw string) (int, error) {
 	if raw == "" {
-		return 5, nil
+		return defaultSearchLimit, nil
 	}
 	value, err := strconv.Atoi(raw)
 	if err != nil || value <= 0 {
@@ -97,32 +222,12 @@ func parseLimit(raw string) (int, error) {
 	return value, nil
 }
 
-func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Allow", httpinfo.InsertMessageEndpoint.Method+", "+httpinfo.GetMessageEndpoint.Method)
-	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
-}
-
-func (h *MessageHandler) dispatchMessages(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case httpinfo.InsertMessageEndpoint.Method:
-		h.handleInsertMessage(w, r)
-	case httpinfo.GetMessageEndpoint.Method:
-		h.handleGetMessage(w, r)
-	default:
-		methodNotAllowed(w, r)
-	}
-}
-
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
-func writeError(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, map[string]string{"error": msg})
-}
-
 type messageDocumentResponse struct {
 	ID       string                 `json:"id,omitempty"`
 	Content  string                 `json:"content"`
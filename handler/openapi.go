@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"vector-database/httpinfo"
+)
+
+// OpenAPIHandler serves the OpenAPI document generated from the httpinfo
+// registry.
+type OpenAPIHandler struct {
+	document []byte
+}
+
+// NewOpenAPIHandler renders the document once, stamped with the build
+// version.
+func NewOpenAPIHandler(version string) (*OpenAPIHandler, error) {
+	document, err := json.MarshalIndent(httpinfo.Spec(version), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("render openapi document: %w", err)
+	}
+	return &OpenAPIHandler{document: document}, nil
+}
+
+// Routes binds the OpenAPI document endpoint.
+func (h *OpenAPIHandler) Routes() []Route {
+	return []Route{
+		{Endpoint: httpinfo.OpenAPIEndpoint, Handle: h.handleOpenAPI},
+	}
+}
+
+func (h *OpenAPIHandler) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(h.document)
+}
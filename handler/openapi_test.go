@@ -0,0 +1,365 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/httpinfo"
+	"vector-database/model"
+	"vector-database/service"
+)
+
+// componentTypes are the Go types each shared schema documents.
+var componentTypes = map[string]reflect.Type{
+	"Error": reflect.TypeFor[struct {
+		Error httpinfo.APIError `json:"error"`
+	}](),
+	"MessageInput":    reflect.TypeFor[insertMessageRequest](),
+	"Message":         reflect.TypeFor[messageDocumentResponse](),
+	"Image":           reflect.TypeFor[model.ImageDocument](),
+	"SearchResults":   reflect.TypeFor[getMessageResponse](),
+	"GroupedResults":  reflect.TypeFor[groupedMessageResponse](),
+	"SimilarMessages": reflect.TypeFor[similarMessagesResponse](),
+	"SimilarImages":   reflect.TypeFor[similarImagesResponse](),
+	"IndexInfo":       reflect.TypeFor[model.IndexInfo](),
+	"Status":          reflect.TypeFor[service.Status](),
+	"Readiness":       reflect.TypeFor[service.Readiness](),
+	"APIKey":          reflect.TypeFor[model.APIKey](),
+}
+
+// bodyTypes are the Go types handlers decode or encode for each JSON body,
+// keyed by operation id and "request" or the response status. A nil type
+// marks a oneOf whose alternatives are checked as components.
+var bodyTypes = map[string]reflect.Type{
+	"getHealth 200":                 reflect.TypeFor[healthResponse](),
+	"getReadiness 200":              reflect.TypeFor[service.Readiness](),
+	"getReadiness 503":              reflect.TypeFor[service.Readiness](),
+	"getOpenAPI 200":                reflect.TypeFor[map[string]interface{}](),
+	"insertMessage request":         reflect.TypeFor[insertMessageRequest](),
+	"insertMessage 201":             reflect.TypeFor[insertMessageResponse](),
+	"searchMessagesByText 200":      nil,
+	"insertMessages request":        reflect.TypeFor[insertMessagesRequest](),
+	"insertMessages 201":            reflect.TypeFor[insertMessagesResponse](),
+	"searchMessagesByTerms request": reflect.TypeFor[searchMessagesRequest](),
+	"searchMessagesByTerms 200":     reflect.TypeFor[getMessageResponse](),
+	"similarMessages 200":           reflect.TypeFor[similarMessagesResponse](),
+	"insertImage 201":               reflect.TypeFor[insertImageResponse](),
+	"searchImages 200":              reflect.TypeFor[searchImageResponse](),
+	"similarImages 200":             reflect.TypeFor[similarImagesResponse](),
+	"trainIndex 200":                reflect.TypeFor[trainIndexResponse](),
+	"trainIndex 409":                componentTypes["Error"],
+	"getStatus 200":                 reflect.TypeFor[service.Status](),
+	"createKey request":             reflect.TypeFor[createKeyRequest](),
+	"createKey 201":                 reflect.TypeFor[createKeyResponse](),
+	"listKeys 200":                  reflect.TypeFor[listKeysResponse](),
+}
+
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	spec := httpinfo.Spec("test")
+
+	for name, schema := range spec.Components.Schemas {
+		typ, ok := componentTypes[name]
+		if !ok {
+			t.Errorf("component %s has no Go type to check against", name)
+			continue
+		}
+		checkSchema(t, spec, "#/components/schemas/"+name, schema, typ)
+	}
+
+	seen := map[string]bool{}
+	for _, ep := range httpinfo.Endpoints {
+		if ep.Request != nil && ep.Request.ContentType == "application/json" {
+			seen[ep.Operation+" request"] = true
+			checkBody(t, spec, ep.Operation+" request", ep.Request.Schema)
+		}
+		for _, resp := range ep.Responses {
+			if resp.Body == nil || resp.Body.ContentType != "application/json" {
+				continue
+			}
+			key := ep.Operation + " " + strconv.Itoa(resp.Status)
+			seen[key] = true
+			checkBody(t, spec, key, resp.Body.Schema)
+		}
+	}
+	for key := range bodyTypes {
+		if !seen[key] {
+			t.Errorf("%s is not documented", key)
+		}
+	}
+}
+
+func checkBody(t *testing.T, spec httpinfo.OpenAPI, key string, schema *httpinfo.Schema) {
+	typ, ok := bodyTypes[key]
+	if !ok {
+		t.Errorf("%s has no Go type to check against", key)
+		return
+	}
+	if typ == nil {
+		for _, alternative := range schema.OneOf {
+			if alternative.Ref == "" {
+				t.Errorf("%s: oneOf alternatives must be components", key)
+			}
+		}
+		return
+	}
+	checkSchema(t, spec, key, schema, typ)
+}
+
+// checkSchema reports where schema disagrees with the JSON encoding of typ:
+// missing or undocumented fields, required fields that may be omitted and
+// mismatched types.
+func checkSchema(t *testing.T, spec httpinfo.OpenAPI, path string, schema *httpinfo.Schema, typ reflect.Type) {
+	t.Helper()
+	if schema.Ref != "" {
+		schema = spec.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == reflect.TypeFor[time.Time]():
+		if schema.Type != "string" || schema.Format != "date-time" {
+			t.Errorf("%s: %s is a date-time string, documented as %s", path, typ, schema.Type)
+		}
+		return
+	case typ == reflect.TypeFor[primitive.ObjectID]():
+		if schema.Type != "string" {
+			t.Errorf("%s: %s is a string, documented as %s", path, typ, schema.Type)
+		}
+		return
+	}
+
+	want := ""
+	switch typ.Kind() {
+	case reflect.String:
+		want = "string"
+	case reflect.Bool:
+		want = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		want = "integer"
+	case reflect.Float32, reflect.Float64:
+		want = "number"
+	case reflect.Slice, reflect.Array:
+		want = "array"
+	case reflect.Map, reflect.Struct:
+		want = "object"
+	}
+	if schema.Type != want {
+		t.Errorf("%s: %s encodes as %q, documented as %q", path, typ, want, schema.Type)
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if schema.Items == nil {
+			t.Errorf("%s: array without items", path)
+			return
+		}
+		checkSchema(t, spec, path+"[]", schema.Items, typ.Elem())
+	case reflect.Map:
+		if len(schema.Properties) > 0 {
+			t.Errorf("%s: %s has arbitrary keys, documented with properties", path, typ)
+		}
+	case reflect.Struct:
+		fields := jsonFields(typ)
+		for name, field := range fields {
+			prop, ok := schema.Properties[name]
+			if !ok {
+				t.Errorf("%s: field %s is not documented", path, name)
+				continue
+			}
+			checkSchema(t, spec, path+"."+name, prop, field.typ)
+		}
+		for name := range schema.Properties {
+			if _, ok := fields[name]; !ok {
+				t.Errorf("%s: documented property %s is not a field", path, name)
+			}
+		}
+		for _, name := range schema.Required {
+			if field, ok := fields[name]; ok && field.omitEmpty {
+				t.Errorf("%s: required property %s is omitempty", path, name)
+			}
+		}
+	}
+}
+
+type jsonField struct {
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// jsonFields lists the properties encoding/json writes for a struct.
+func jsonFields(typ reflect.Type) map[string]jsonField {
+	fields := map[string]jsonField{}
+	for _, field := range reflect.VisibleFields(typ) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = jsonField{typ: field.Type, omitEmpty: slices.Contains(strings.Split(options, ","), "omitempty")}
+	}
+	return fields
+}
+
+var (
+	pathParam   = regexp.MustCompile(`\{([^}]+)\}`)
+	statusCode  = regexp.MustCompile(`^[1-5][0-9][0-9]$`)
+	schemaTypes = []string{"", "string", "integer", "number", "boolean", "array", "object"}
+)
+
+// TestOpenAPIDocumentIsValid checks the rules of the OpenAPI 3.1
+// specification the document could break as endpoints are added.
+func TestOpenAPIDocumentIsValid(t *testing.T) {
+	spec := httpinfo.Spec("test")
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"openapi", "info", "paths"} {
+		if doc[field] == nil {
+			t.Errorf("missing required field %s", field)
+		}
+	}
+	if spec.OpenAPI != "3.1.0" || spec.Info.Title == "" || spec.Info.Version == "" {
+		t.Errorf("header = %s %+v", spec.OpenAPI, spec.Info)
+	}
+
+	operations := map[string]bool{}
+	for path, methods := range spec.Paths {
+		if !strings.HasPrefix(path, "/") {
+			t.Errorf("path %s does not start with /", path)
+		}
+		var templated []string
+		for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+			templated = append(templated, match[1])
+		}
+
+		for method, op := range methods {
+			where := method + " " + path
+			if !slices.Contains([]string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}, method) {
+				t.Errorf("%s: unknown method", where)
+			}
+			if op.OperationID == "" || operations[op.OperationID] {
+				t.Errorf("%s: operationId %q is empty or not unique", where, op.OperationID)
+			}
+			operations[op.OperationID] = true
+
+			var declared []string
+			params := map[string]bool{}
+			for _, param := range op.Parameters {
+				if !slices.Contains([]string{"query", "header", "path", "cookie"}, param.In) {
+					t.Errorf("%s: parameter %s is in %q", where, param.Name, param.In)
+				}
+				if params[param.In+" "+param.Name] {
+					t.Errorf("%s: parameter %s is declared twice", where, param.Name)
+				}
+				params[param.In+" "+param.Name] = true
+				if param.In == "path" {
+					declared = append(declared, param.Name)
+					if !param.Required {
+						t.Errorf("%s: path parameter %s must be required", where, param.Name)
+					}
+				}
+				checkValidSchema(t, spec, where+" "+param.Name, param.Schema)
+			}
+			slices.Sort(templated)
+			slices.Sort(declared)
+			if !slices.Equal(templated, declared) {
+				t.Errorf("%s: path parameters %v, template has %v", where, declared, templated)
+			}
+
+			if op.RequestBody != nil {
+				for _, media := range op.RequestBody.Content {
+					checkValidSchema(t, spec, where+" request", media.Schema)
+				}
+			}
+			if len(op.Responses) == 0 {
+				t.Errorf("%s: no responses", where)
+			}
+			success := false
+			for code, resp := range op.Responses {
+				if code != "default" && !statusCode.MatchString(code) {
+					t.Errorf("%s: response key %q", where, code)
+				}
+				success = success || strings.HasPrefix(code, "2")
+				if resp.Description == "" {
+					t.Errorf("%s %s: response without description", where, code)
+				}
+				for _, media := range resp.Content {
+					checkValidSchema(t, spec, where+" "+code, media.Schema)
+				}
+			}
+			if !success {
+				t.Errorf("%s: no success response", where)
+			}
+			for _, requirement := range op.Security {
+				for scheme := range requirement {
+					if _, ok := spec.Components.SecuritySchemes[scheme]; !ok {
+						t.Errorf("%s: unknown security scheme %s", where, scheme)
+					}
+				}
+			}
+		}
+	}
+	for name, schema := range spec.Components.Schemas {
+		checkValidSchema(t, spec, name, schema)
+	}
+}
+
+// checkValidSchema checks references resolve and types are JSON Schema
+// types.
+func checkValidSchema(t *testing.T, spec httpinfo.OpenAPI, path string, schema *httpinfo.Schema) {
+	t.Helper()
+	if schema == nil {
+		t.Errorf("%s: missing schema", path)
+		return
+	}
+	if schema.Ref != "" {
+		if _, ok := spec.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]; !ok {
+			t.Errorf("%s: unresolved $ref %s", path, schema.Ref)
+		}
+	}
+	if !slices.Contains(schemaTypes, schema.Type) {
+		t.Errorf("%s: unknown type %q", path, schema.Type)
+	}
+	if schema.Type == "array" && schema.Items == nil {
+		t.Errorf("%s: array without items", path)
+	}
+	if schema.Items != nil {
+		checkValidSchema(t, spec, path+"[]", schema.Items)
+	}
+	for name, prop := range schema.Properties {
+		checkValidSchema(t, spec, path+"."+name, prop)
+	}
+	for _, name := range schema.Required {
+		if _, ok := schema.Properties[name]; !ok {
+			t.Errorf("%s: required property %s is not defined", path, name)
+		}
+	}
+	for i, alternative := range schema.OneOf {
+		checkValidSchema(t, spec, path+".oneOf["+strconv.Itoa(i)+"]", alternative)
+	}
+	if schema.Minimum != nil && schema.Maximum != nil && *schema.Minimum > *schema.Maximum {
+		t.Errorf("%s: minimum above maximum", path)
+	}
+}
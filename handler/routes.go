@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"vector-database/httpinfo"
+)
+
+// Route binds an endpoint of the httpinfo registry to the function serving
+// it.
+type Route struct {
+	Endpoint httpinfo.Endpoint
+	Handle   http.HandlerFunc
+}
+
+// Mount registers routes on mux with one pattern per path. Requests for a
+// method no route serves get 405 with an Allow header, and HEAD is served
+// by the GET route. It panics when a route is not in httpinfo.Endpoints or
+// is bound twice, so the registry and the mux cannot drift apart.
+func Mount(mux *http.ServeMux, routes ...Route) {
+	var paths []string
+	byPath := map[string]map[string]http.HandlerFunc{}
+	for _, route := range routes {
+		ep := route.Endpoint
+		if registered, ok := httpinfo.Lookup(ep.Method, ep.Path); !ok || registered.Path != ep.Path {
+			panic(fmt.Sprintf("handler: %s %s is not a registered endpoint", ep.Method, ep.Path))
+		}
+		methods, ok := byPath[ep.Path]
+		if !ok {
+			methods = map[string]http.HandlerFunc{}
+			byPath[ep.Path] = methods
+			paths = append(paths, ep.Path)
+		}
+		if _, dup := methods[ep.Method]; dup {
+			panic(fmt.Sprintf("handler: %s %s is bound twice", ep.Method, ep.Path))
+		}
+		methods[ep.Method] = route.Handle
+	}
+
+	for _, path := range paths {
+		mux.Handle(path, dispatch(path, byPath[path]))
+	}
+}
+
+// dispatch picks the route by method. Allow lists the methods in registry
+// order so the header is stable.
+func dispatch(path string, methods map[string]http.HandlerFunc) http.Handler {
+	var allowed []string
+	for _, ep := range httpinfo.Endpoints {
+		if _, ok := methods[ep.Method]; ok && ep.Path == path {
+			allowed = append(allowed, ep.Method)
+		}
+	}
+	allow := strings.Join(allowed, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handle, ok := methods[r.Method]
+		if !ok && r.Method == http.MethodHead {
+			handle, ok = methods[http.MethodGet]
+		}
+		if !ok {
+			w.Header().Set("Allow", allow)
+			writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		handle(w, r)
+	})
+}
+
+// Unmatched wraps mux so the 404 and 405 responses ServeMux writes itself,
+// for requests no route serves, carry the JSON error body instead of plain
+// text. Its redirects pass through.
+func Unmatched(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(&unmatchedWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+// unmatchedWriter replaces a 404 or 405 with the JSON error body and drops
+// the plain text ServeMux writes after it.
+type unmatchedWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	replaced bool
+}
+
+func (w *unmatchedWriter) WriteHeader(status int) {
+	switch status {
+	case http.StatusNotFound:
+		writeError(w.ResponseWriter, w.r, status, "no route for "+w.r.URL.Path)
+	case http.StatusMethodNotAllowed:
+		writeError(w.ResponseWriter, w.r, status, "method not allowed")
+	default:
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.replaced = true
+}
+
+func (w *unmatchedWriter) Write(b []byte) (int, error) {
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
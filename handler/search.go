@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"vector-database/model"
+)
+
+type searchTermRequest struct {
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+	Weight *float64  `json:"weight"`
+}
+
+// searchMessagesRequest holds a composite query, either as the weighted
+// query syntax or as explicit terms, plus the usual search options.
+type searchMessagesRequest struct {
+	Query    string              `json:"query"`
+	Terms    []searchTermRequest `json:"terms"`
+	Limit    int                 `json:"limit"`
+	Exact    bool                `json:"exact"`
+	MinScore float64             `json:"min_score"`
+	Offset   int                 `json:"offset"`
+	Cursor   string              `json:"cursor"`
+	MMR      bool                `json:"mmr"`
+	Lambda   *float64            `json:"lambda"`
+	Rerank   bool                `json:"rerank"`
+}
+
+func (req searchMessagesRequest) termQuery() (model.TermQuery, error) {
+	var query model.TermQuery
+	if strings.TrimSpace(req.Query) != "" {
+		terms, err := model.ParseWeightedQuery(req.Query)
+		if err != nil {
+			return query, err
+		}
+		query.Terms = terms
+	}
+	for _, term := range req.Terms {
+		weight := 1.0
+		if term.Weight != nil {
+			weight = *term.Weight
+		}
+		query.Terms = append(query.Terms, model.WeightedTerm{
+			Text:   term.Text,
+			Vector: term.Vector,
+			Weight: weight,
+		})
+	}
+
+	opts := model.SearchOptions{
+		Limit:     req.Limit,
+		Exact:     req.Exact,
+		MinScore:  req.MinScore,
+		Offset:    req.Offset,
+		MMR:       req.MMR,
+		MMRLambda: req.Lambda,
+		Rerank:    req.Rerank,
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaultSearchLimit
+	}
+	if opts.Limit < 0 {
+		return query, errors.New("limit must be a positive integer")
+	}
+	opts, err := checkSearchOptions(opts, strings.TrimSpace(req.Cursor))
+	if err != nil {
+		return query, err
+	}
+
+	query.Options = opts
+	return query, nil
+}
+
+func (h *MessageHandler) handleSearchMessages(w http.ResponseWriter, r *http.Request) {
+	var req searchMessagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid json payload")
+		return
+	}
+
+	query, err := req.termQuery()
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	res, err := h.service.SearchByTerms(r.Context(), query)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := getMessageResponse{
+		Query:   req.Query,
+		Results: toMessageResponses(res),
+	}
+	if len(res) > 0 {
+		last := res[len(res)-1]
+		resp.NextCursor = query.Options.NextCursor(len(res), last.Score, last.ID.Hex())
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
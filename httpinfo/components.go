@@ -0,0 +1,101 @@
+package httpinfo
+
+import "vector-database/config"
+
+// Components are the shared schemas endpoints refer to with refSchema.
+var Components = map[string]*Schema{
+	"Error": objectSchema(map[string]*Schema{
+		"error": objectSchema(map[string]*Schema{
+			"code": enumSchema(CodeInvalidArgument, CodeDimensionMismatch, CodeUnauthenticated, CodeForbidden,
+				CodeNotFound, CodeMethodNotAllowed, CodeConflict, CodeFailedPrecondition, CodeRateLimited, CodeClientError,
+				CodeInternal, CodeNotImplemented, CodeUnavailable),
+			"message":    stringSchema().Describe("What went wrong"),
+			"details":    mapSchema().Describe("Machine-readable context, such as expected and got for dimension_mismatch or retry_after_seconds for rate_limited"),
+			"request_id": stringSchema().Describe("The X-Request-ID of the failed request"),
+		}, "code", "message"),
+	}, "error"),
+	"MessageInput": objectSchema(map[string]*Schema{
+		"content":  stringSchema().Describe("Text to embed"),
+		"metadata": mapSchema().Describe("Arbitrary JSON stored with the message and usable in filters"),
+	}, "content"),
+	"Message": objectSchema(map[string]*Schema{
+		"id":       stringSchema().Describe("Hex ObjectID"),
+		"content":  stringSchema(),
+		"metadata": mapSchema(),
+		"score":    numberSchema().Describe("Similarity to the query, for search results"),
+	}, "content"),
+	"Image": objectSchema(map[string]*Schema{
+		"id":          stringSchema().Describe("Hex ObjectID"),
+		"description": stringSchema(),
+		"metadata":    mapSchema(),
+		"score":       numberSchema().Describe("Similarity to the query, for search results"),
+	}, "description"),
+	"SearchResults": objectSchema(map[string]*Schema{
+		"query":       stringSchema(),
+		"results":     arraySchema(refSchema("Message")),
+		"next_cursor": stringSchema().Describe("Pass as cursor to fetch the next page; absent on the last page"),
+	}, "results"),
+	"GroupedResults": objectSchema(map[string]*Schema{
+		"query":    stringSchema(),
+		"group_by": stringSchema(),
+		"groups": arraySchema(objectSchema(map[string]*Schema{
+			"value":   freeformSchema().Describe("Value of the grouped field, null for documents without it"),
+			"count":   integerSchema().Describe("Candidates sharing the value"),
+			"results": arraySchema(refSchema("Message")),
+		}, "value", "count", "results")),
+		"facets": arraySchema(objectSchema(map[string]*Schema{
+			"value": freeformSchema(),
+			"count": integerSchema(),
+		}, "value", "count")),
+	}, "groups", "facets"),
+	"SimilarMessages": objectSchema(map[string]*Schema{
+		"id":      stringSchema().Describe("The source message"),
+		"results": arraySchema(refSchema("Message")),
+	}, "id", "results"),
+	"SimilarImages": objectSchema(map[string]*Schema{
+		"id":      stringSchema().Describe("The source image"),
+		"results": arraySchema(refSchema("Image")),
+	}, "id", "results"),
+	"IndexInfo": objectSchema(map[string]*Schema{
+		"type":        stringSchema(),
+		"lists":       integerSchema(),
+		"sub_vectors": integerSchema(),
+		"vectors":     integerSchema().Describe("Vectors the index was trained on"),
+	}),
+	"Status": objectSchema(map[string]*Schema{
+		"version":    stringSchema(),
+		"started_at": &Schema{Type: "string", Format: "date-time"},
+		"uptime":     stringSchema(),
+		"store": objectSchema(map[string]*Schema{
+			"type":      stringSchema(),
+			"documents": integerSchema().Describe("Documents of the caller's tenant"),
+			"index": objectSchema(map[string]*Schema{
+				"name":      stringSchema(),
+				"status":    stringSchema(),
+				"queryable": booleanSchema(),
+			}),
+		}),
+		"cache": objectSchema(map[string]*Schema{
+			"memory_hits":     integerSchema(),
+			"persistent_hits": integerSchema(),
+			"misses":          integerSchema(),
+			"errors":          integerSchema(),
+		}).Describe("Embedding cache counters, when the cache is enabled"),
+	}, "version", "started_at", "uptime", "store"),
+	"Readiness": objectSchema(map[string]*Schema{
+		"ready": booleanSchema(),
+		"checks": arraySchema(objectSchema(map[string]*Schema{
+			"name":  enumSchema("mongo", "index", "encoder"),
+			"ok":    booleanSchema(),
+			"error": stringSchema(),
+		}, "name", "ok")),
+	}, "ready", "checks"),
+	"APIKey": objectSchema(map[string]*Schema{
+		"id":         stringSchema(),
+		"name":       stringSchema(),
+		"scopes":     arraySchema(enumSchema(config.Scopes...)),
+		"tenant":     stringSchema(),
+		"created_by": stringSchema().Describe("Name of the key that created this one"),
+		"created_at": &Schema{Type: "string", Format: "date-time"},
+	}, "id", "name", "scopes", "tenant", "created_at"),
+}
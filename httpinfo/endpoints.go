@@ -1,16 +1,31 @@
 package httpinfo
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
 
+	"vector-database/config"
+	"vector-database/model"
+)
+
+// Endpoint describes a route: how it is matched, the API key scope it
+// requires and, for the OpenAPI document, its parameters and payloads.
 type Endpoint struct {
 	Method      string
 	Path        string
 	Description string
+	Scope       string
+	Operation   string
+	Tag         string
+	Params      []Param
+	Request     *Body
+	Responses   []Response
 }
 
 const (
 	DefaultAddr = ":8080"
 	basePath    = "/api"
+	adminPath   = basePath + "/admin"
 )
 
 var (
@@ -18,20 +33,360 @@ var (
 		Method:      http.MethodPost,
 		Path:        basePath + "/messages",
 		Description: "Insert a message and store its embedding",
+		Scope:       config.ScopeMessagesWrite,
+		Operation:   "insertMessage",
+		Tag:         "messages",
+		Request:     jsonBody(refSchema("MessageInput")),
+		Responses: []Response{
+			{Status: http.StatusCreated, Description: "The stored message", Body: jsonBody(objectSchema(map[string]*Schema{
+				"document": refSchema("Message"),
+			}, "document"))},
+		},
 	}
 	GetMessageEndpoint = Endpoint{
 		Method:      http.MethodGet,
 		Path:        basePath + "/messages",
 		Description: "Retrieve messages via semantic search",
+		Scope:       config.ScopeSearchRead,
+		Operation:   "searchMessagesByText",
+		Tag:         "messages",
+		Params: append([]Param{
+			{Name: "q", In: "query", Description: "Text to search for", Required: true, Schema: stringSchema()},
+		}, append(searchParams(true, true),
+			queryParam("group_by", "Bucket results by a metadata field such as metadata.topic; limit then counts groups", stringSchema()),
+			queryParam("group_size", "Results kept per group", integerSchema().AtLeast(1).Defaults(model.DefaultGroupSize)),
+		)...),
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Matching messages, or groups of them when group_by is set", Body: jsonBody(&Schema{
+				OneOf: []*Schema{refSchema("SearchResults"), refSchema("GroupedResults")},
+			})},
+		},
+	}
+	InsertMessagesEndpoint = Endpoint{
+		Method:      http.MethodPost,
+		Path:        basePath + "/messages/batch",
+		Description: "Insert many messages at once, encoding them in batches",
+		Scope:       config.ScopeMessagesWrite,
+		Operation:   "insertMessages",
+		Tag:         "messages",
+		Request: jsonBody(objectSchema(map[string]*Schema{
+			"messages": {Type: "array", Items: refSchema("MessageInput"), MaxItems: 1000},
+		}, "messages")),
+		Responses: []Response{
+			{Status: http.StatusCreated, Description: "The stored messages, in request order", Body: jsonBody(objectSchema(map[string]*Schema{
+				"documents": arraySchema(refSchema("Message")),
+			}, "documents"))},
+		},
+	}
+	SearchMessagesEndpoint = Endpoint{
+		Method:      http.MethodPost,
+		Path:        basePath + "/messages/search",
+		Description: "Search messages with several weighted text and vector terms",
+		Scope:       config.ScopeSearchRead,
+		Operation:   "searchMessagesByTerms",
+		Tag:         "messages",
+		Request: jsonBody(paramsSchema(searchParams(true, true), map[string]*Schema{
+			"query": stringSchema().Describe(`Weighted query such as +0.7 "beach sunset" -0.3 people`),
+			"terms": arraySchema(objectSchema(map[string]*Schema{
+				"text":   stringSchema(),
+				"vector": arraySchema(numberSchema()),
+				"weight": numberSchema().Defaults(1).Describe("Negative weights push results away from the term"),
+			})).Describe("Terms with either text or a vector"),
+		})),
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Matching messages", Body: jsonBody(refSchema("SearchResults"))},
+		},
+	}
+	SimilarMessagesEndpoint = Endpoint{
+		Method:      http.MethodGet,
+		Path:        basePath + "/messages/{id}/similar",
+		Description: "Find messages similar to a stored message, optionally steered by positive/negative examples",
+		Scope:       config.ScopeSearchRead,
+		Operation:   "similarMessages",
+		Tag:         "messages",
+		Params:      append([]Param{pathParam("id", "Source message id")}, similarParams()...),
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Similar messages, excluding every referenced one", Body: jsonBody(refSchema("SimilarMessages"))},
+		},
 	}
 	InsertImageEndpoint = Endpoint{
 		Method:      http.MethodPost,
 		Path:        basePath + "/images",
 		Description: "Insert an image with a textual description and store its embedding",
+		Scope:       config.ScopeImagesWrite,
+		Operation:   "insertImage",
+		Tag:         "images",
+		Request: &Body{ContentType: multipartContent, Schema: objectSchema(map[string]*Schema{
+			"image":       binarySchema().Describe("Image file, at most 10 MB"),
+			"description": stringSchema(),
+			"metadata":    stringSchema().Describe("JSON object stored with the image"),
+		}, "image")},
+		Responses: []Response{
+			{Status: http.StatusCreated, Description: "The stored image", Body: jsonBody(objectSchema(map[string]*Schema{
+				"image": refSchema("Image"),
+			}, "image"))},
+		},
 	}
 	SearchImageEndpoint = Endpoint{
 		Method:      http.MethodPost,
 		Path:        basePath + "/images/search",
 		Description: "Find images whose embeddings are similar to the provided payload",
+		Scope:       config.ScopeSearchRead,
+		Operation:   "searchImages",
+		Tag:         "images",
+		Request: &Body{ContentType: multipartContent, Schema: paramsSchema(searchParams(true, true), map[string]*Schema{
+			"image":       binarySchema().Describe("Image file, at most 10 MB"),
+			"description": stringSchema(),
+		}, "image")},
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Matching images", Body: jsonBody(objectSchema(map[string]*Schema{
+				"results":     arraySchema(refSchema("Image")),
+				"next_cursor": stringSchema(),
+			}, "results"))},
+		},
+	}
+	SimilarImagesEndpoint = Endpoint{
+		Method:      http.MethodGet,
+		Path:        basePath + "/images/{id}/similar",
+		Description: "Find images similar to a stored image, optionally steered by positive/negative examples",
+		Scope:       config.ScopeSearchRead,
+		Operation:   "similarImages",
+		Tag:         "images",
+		Params:      append([]Param{pathParam("id", "Source image id")}, similarParams()...),
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Similar images, excluding every referenced one", Body: jsonBody(refSchema("SimilarImages"))},
+		},
+	}
+	TrainIndexEndpoint = Endpoint{
+		Method:      http.MethodPost,
+		Path:        adminPath + "/index/train",
+		Description: "Train the IVF-PQ index of the embedded store on the stored vectors",
+		Scope:       config.ScopeAdmin,
+		Operation:   "trainIndex",
+		Tag:         "admin",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The trained index", Body: jsonBody(objectSchema(map[string]*Schema{
+				"index": refSchema("IndexInfo"),
+			}, "index"))},
+			{Status: http.StatusConflict, Description: "failed_precondition: the store holds no vectors to train on", Body: jsonBody(refSchema("Error"))},
+		},
+	}
+	StatusEndpoint = Endpoint{
+		Method:      http.MethodGet,
+		Path:        adminPath + "/status",
+		Description: "Report version, uptime, document count and index status",
+		Scope:       config.ScopeAdmin,
+		Operation:   "getStatus",
+		Tag:         "admin",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Service status", Body: jsonBody(refSchema("Status"))},
+		},
+	}
+	CreateKeyEndpoint = Endpoint{
+		Method:      http.MethodPost,
+		Path:        adminPath + "/keys",
+		Description: "Create an API key; the secret is only returned once",
+		Scope:       config.ScopeAdmin,
+		Operation:   "createKey",
+		Tag:         "admin",
+		Request: jsonBody(objectSchema(map[string]*Schema{
+			"name":   stringSchema(),
+			"scopes": arraySchema(enumSchema(config.Scopes...)),
+			"tenant": stringSchema().Describe("Must be the tenant of the creating key, which is also the default"),
+		}, "name", "scopes")),
+		Responses: []Response{
+			{Status: http.StatusCreated, Description: "The key and its secret", Body: jsonBody(objectSchema(map[string]*Schema{
+				"key":    refSchema("APIKey"),
+				"secret": stringSchema().Describe("Send as a bearer token; it cannot be retrieved again"),
+			}, "key", "secret"))},
+		},
+	}
+	ListKeysEndpoint = Endpoint{
+		Method:      http.MethodGet,
+		Path:        adminPath + "/keys",
+		Description: "List the stored API keys of the caller's tenant",
+		Scope:       config.ScopeAdmin,
+		Operation:   "listKeys",
+		Tag:         "admin",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Stored keys, without secrets", Body: jsonBody(objectSchema(map[string]*Schema{
+				"keys": arraySchema(refSchema("APIKey")),
+			}, "keys"))},
+		},
+	}
+	DeleteKeyEndpoint = Endpoint{
+		Method:      http.MethodDelete,
+		Path:        adminPath + "/keys/{id}",
+		Description: "Revoke a stored API key",
+		Scope:       config.ScopeAdmin,
+		Operation:   "revokeKey",
+		Tag:         "admin",
+		Params:      []Param{pathParam("id", "Key id")},
+		Responses:   []Response{{Status: http.StatusNoContent, Description: "The key was revoked"}},
+	}
+	OpenAPIEndpoint = Endpoint{
+		Method:      http.MethodGet,
+		Path:        basePath + "/openapi.json",
+		Description: "This OpenAPI 3.1 document",
+		Operation:   "getOpenAPI",
+		Tag:         "meta",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The OpenAPI document", Body: jsonBody(mapSchema())},
+		},
+	}
+)
+
+// Probe and metrics endpoints live outside basePath and are served without
+// authentication or rate limiting so orchestrators and scrapers can always
+// reach them. The OpenAPI document is served the same way.
+var (
+	HealthEndpoint = Endpoint{
+		Method:      http.MethodGet,
+		Path:        "/healthz",
+		Description: "Liveness: the process is up",
+		Operation:   "getHealth",
+		Tag:         "meta",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "The process is up", Body: jsonBody(objectSchema(map[string]*Schema{
+				"status": enumSchema("ok"),
+			}, "status"))},
+		},
+	}
+	ReadyEndpoint = Endpoint{
+		Method:      http.MethodGet,
+		Path:        "/readyz",
+		Description: "Readiness: Mongo is reachable, the vector index is queryable and the encoder works",
+		Operation:   "getReadiness",
+		Tag:         "meta",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Every check passed", Body: jsonBody(refSchema("Readiness"))},
+			{Status: http.StatusServiceUnavailable, Description: "At least one check failed", Body: jsonBody(refSchema("Readiness"))},
+		},
+	}
+	MetricsEndpoint = Endpoint{
+		Method:      http.MethodGet,
+		Path:        "/metrics",
+		Description: "Prometheus metrics in the text exposition format",
+		Operation:   "getMetrics",
+		Tag:         "meta",
+		Responses: []Response{
+			{Status: http.StatusOK, Description: "Metrics", Body: &Body{ContentType: "text/plain", Schema: stringSchema()}},
+		},
 	}
 )
+
+// Endpoints lists every route. It is the registry handlers are mounted
+// from and the OpenAPI document is generated from.
+var Endpoints = []Endpoint{
+	HealthEndpoint,
+	ReadyEndpoint,
+	MetricsEndpoint,
+	OpenAPIEndpoint,
+	InsertMessageEndpoint,
+	GetMessageEndpoint,
+	InsertMessagesEndpoint,
+	SearchMessagesEndpoint,
+	SimilarMessagesEndpoint,
+	InsertImageEndpoint,
+	SearchImageEndpoint,
+	SimilarImagesEndpoint,
+	TrainIndexEndpoint,
+	StatusEndpoint,
+	CreateKeyEndpoint,
+	ListKeysEndpoint,
+	DeleteKeyEndpoint,
+}
+
+// searchParams are the search options shared by the search endpoints.
+func searchParams(cursor, rerank bool) []Param {
+	params := []Param{
+		queryParam("limit", "Maximum number of results", integerSchema().AtLeast(1).Defaults(5)),
+		queryParam("exact", "Rescore candidates with exact cosine similarity", booleanSchema().Defaults(false)),
+		queryParam("min_score", "Drop results scoring below this similarity", numberSchema()),
+		queryParam("offset", "Results to skip; cannot be combined with cursor", integerSchema().AtLeast(0).Defaults(0)),
+		queryParam("mmr", "Diversify results with maximal marginal relevance", booleanSchema().Defaults(false)),
+		queryParam("lambda", "MMR trade-off between relevance (1) and diversity (0)", numberSchema().Between(0, 1).Defaults(model.DefaultMMRLambda)),
+	}
+	if cursor {
+		params = append(params, queryParam("cursor", "next_cursor of the previous page", stringSchema()))
+	}
+	if rerank {
+		params = append(params, queryParam("rerank", "Rerank candidates with the configured rerankers", booleanSchema().Defaults(false)))
+	}
+	return params
+}
+
+func similarParams() []Param {
+	return append(searchParams(false, false),
+		queryParam("positive", "Ids of examples to steer towards, repeated or comma separated; at most 100", arraySchema(stringSchema())),
+		queryParam("negative", "Ids of examples to steer away from, repeated or comma separated; at most 100", arraySchema(stringSchema())),
+	)
+}
+
+// paramsSchema describes a body holding params as fields, plus extra ones.
+func paramsSchema(params []Param, extra map[string]*Schema, required ...string) *Schema {
+	props := make(map[string]*Schema, len(params)+len(extra))
+	for _, param := range params {
+		schema := *param.Schema
+		schema.Description = param.Description
+		props[param.Name] = &schema
+	}
+	for name, schema := range extra {
+		props[name] = schema
+	}
+	return objectSchema(props, required...)
+}
+
+// Lookup finds the endpoint serving method and path. Like http.ServeMux,
+// literal segments win over {wildcards}. HEAD resolves to the GET endpoint
+// that serves it, so it needs the same scope and shares its route.
+func Lookup(method, path string) (Endpoint, bool) {
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	var (
+		best      Endpoint
+		bestWilds = -1
+	)
+	for _, ep := range Endpoints {
+		if ep.Method != method {
+			continue
+		}
+		wilds, ok := matchPath(ep.Path, path)
+		if ok && (bestWilds < 0 || wilds < bestWilds) {
+			best, bestWilds = ep, wilds
+		}
+	}
+	return best, bestWilds >= 0
+}
+
+// matchPath reports whether path matches pattern and how many wildcard
+// segments it took.
+func matchPath(pattern, path string) (int, bool) {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return 0, false
+	}
+	wilds := 0
+	for i, segment := range want {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			wilds++
+			continue
+		}
+		if segment != got[i] {
+			return 0, false
+		}
+	}
+	return wilds, true
+}
+
+// ScopeFor returns the scope required by the endpoint serving r, or "" when
+// no endpoint matches and the mux will answer with 404 or 405.
+func ScopeFor(r *http.Request) string {
+	ep, ok := Lookup(r.Method, r.URL.Path)
+	if !ok {
+		return ""
+	}
+	return ep.Scope
+}
@@ -0,0 +1,31 @@
+package httpinfo
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	cases := []struct {
+		method string
+		path   string
+		want   string
+		ok     bool
+	}{
+		{http.MethodGet, "/api/messages", GetMessageEndpoint.Operation, true},
+		{http.MethodPost, "/api/messages", InsertMessageEndpoint.Operation, true},
+		{http.MethodHead, "/api/messages", GetMessageEndpoint.Operation, true},
+		{http.MethodPost, "/api/messages/search", SearchMessagesEndpoint.Operation, true},
+		{http.MethodGet, "/api/messages/67009e42b3f629343e58802a/similar", SimilarMessagesEndpoint.Operation, true},
+		{http.MethodDelete, "/api/admin/keys/k1", DeleteKeyEndpoint.Operation, true},
+		{http.MethodPut, "/api/messages", "", false},
+		{http.MethodGet, "/api/nowhere", "", false},
+		{http.MethodGet, "/api/messages/a/b/similar", "", false},
+	}
+	for _, tc := range cases {
+		ep, ok := Lookup(tc.method, tc.path)
+		if ok != tc.ok || ep.Operation != tc.want {
+			t.Errorf("Lookup(%s, %s) = %q, %v, want %q, %v", tc.method, tc.path, ep.Operation, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package httpinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"vector-database/requestid"
+)
+
+// Error codes returned in the code field of error bodies.
+const (
+	CodeInvalidArgument    = "invalid_argument"
+	CodeDimensionMismatch  = "dimension_mismatch"
+	CodeUnauthenticated    = "unauthenticated"
+	CodeForbidden          = "forbidden"
+	CodeNotFound           = "not_found"
+	CodeMethodNotAllowed   = "method_not_allowed"
+	CodeConflict           = "conflict"
+	CodeFailedPrecondition = "failed_precondition"
+	CodeRateLimited        = "rate_limited"
+	CodeClientError        = "client_error"
+	CodeInternal           = "internal"
+	CodeNotImplemented     = "not_implemented"
+	CodeUnavailable        = "unavailable"
+)
+
+// CodeForStatus returns the code an error response with status carries
+// when nothing more specific is known. It is empty below 400.
+func CodeForStatus(status int) string {
+	switch {
+	case status < 400:
+		return ""
+	case status == http.StatusBadRequest:
+		return CodeInvalidArgument
+	case status == http.StatusUnauthorized:
+		return CodeUnauthenticated
+	case status == http.StatusForbidden:
+		return CodeForbidden
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case status == http.StatusConflict:
+		return CodeConflict
+	case status == http.StatusTooManyRequests:
+		return CodeRateLimited
+	case status < 500:
+		return CodeClientError
+	case status == http.StatusNotImplemented:
+		return CodeNotImplemented
+	case status == http.StatusServiceUnavailable:
+		return CodeUnavailable
+	default:
+		return CodeInternal
+	}
+}
+
+// APIError is the body of every error response, nested under "error".
+type APIError struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+type errorEnvelope struct {
+	Error APIError `json:"error"`
+}
+
+type codeKey struct{}
+
+// RecordCode returns a copy of ctx in which WriteError records the code it
+// writes, and a function returning that code, or "" when no error body was
+// written.
+func RecordCode(ctx context.Context) (context.Context, func() string) {
+	code := new(string)
+	return context.WithValue(ctx, codeKey{}, code), func() string { return *code }
+}
+
+// WriteError writes e with status, filling in the code from the status
+// when it is empty and the request id from the request context.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, e APIError) {
+	if e.Code == "" {
+		e.Code = CodeForStatus(status)
+	}
+	if code, ok := r.Context().Value(codeKey{}).(*string); ok {
+		*code = e.Code
+	}
+	e.RequestID = requestid.FromContext(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorEnvelope{Error: e})
+}
@@ -0,0 +1,131 @@
+package httpinfo
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI documents the API as an OpenAPI 3.1 description.
+type OpenAPI struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       OpenAPIInfo                     `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components OpenAPIComponents               `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Operation is one method of a path.
+type Operation struct {
+	OperationID string                    `json:"operationId"`
+	Summary     string                    `json:"summary"`
+	Description string                    `json:"description,omitempty"`
+	Tags        []string                  `json:"tags,omitempty"`
+	Parameters  []Param                   `json:"parameters,omitempty"`
+	RequestBody *RequestBody              `json:"requestBody,omitempty"`
+	Responses   map[string]ResponseObject `json:"responses"`
+	Security    []map[string][]string     `json:"security,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type ResponseObject struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Security scheme names; a key may be sent either way.
+const (
+	bearerScheme = "bearer"
+	apiKeyScheme = "apiKey"
+)
+
+// Spec builds the OpenAPI document for every endpoint in Endpoints.
+// Endpoints with a scope list both security schemes and answer 401 and 403;
+// every endpoint documents the shared error body as its default response.
+func Spec(version string) OpenAPI {
+	spec := OpenAPI{
+		OpenAPI: "3.1.0",
+		Info:    OpenAPIInfo{Title: "Vector Database API", Version: version},
+		Paths:   map[string]map[string]Operation{},
+		Components: OpenAPIComponents{
+			Schemas: Components,
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer"},
+				apiKeyScheme: {Type: "apiKey", In: "header", Name: "X-API-Key"},
+			},
+		},
+	}
+
+	for _, ep := range Endpoints {
+		op := Operation{
+			OperationID: ep.Operation,
+			Summary:     ep.Description,
+			Parameters:  ep.Params,
+			Responses:   map[string]ResponseObject{},
+		}
+		if ep.Tag != "" {
+			op.Tags = []string{ep.Tag}
+		}
+		if ep.Request != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: content(ep.Request)}
+		}
+		for _, resp := range ep.Responses {
+			obj := ResponseObject{Description: resp.Description}
+			if resp.Body != nil {
+				obj.Content = content(resp.Body)
+			}
+			op.Responses[strconv.Itoa(resp.Status)] = obj
+		}
+		if ep.Scope != "" {
+			op.Description = "Requires the " + ep.Scope + " scope when authentication is enabled."
+			op.Security = []map[string][]string{{bearerScheme: {}}, {apiKeyScheme: {}}}
+			op.Responses[strconv.Itoa(http.StatusUnauthorized)] = errorResponse("The API key is missing or unknown")
+			op.Responses[strconv.Itoa(http.StatusForbidden)] = errorResponse("The API key lacks the " + ep.Scope + " scope")
+		}
+		op.Responses["default"] = errorResponse("The request failed")
+
+		methods, ok := spec.Paths[ep.Path]
+		if !ok {
+			methods = map[string]Operation{}
+			spec.Paths[ep.Path] = methods
+		}
+		methods[strings.ToLower(ep.Method)] = op
+	}
+	return spec
+}
+
+func errorResponse(description string) ResponseObject {
+	return ResponseObject{Description: description, Content: content(jsonBody(refSchema("Error")))}
+}
+
+func content(body *Body) map[string]MediaType {
+	contentType := body.ContentType
+	if contentType == "" {
+		contentType = jsonContent
+	}
+	return map[string]MediaType{contentType: {Schema: body.Schema}}
+}
@@ -0,0 +1,131 @@
+package httpinfo
+
+// Schema is the subset of JSON Schema 2020-12 used to describe request and
+// response bodies in the OpenAPI document.
+type Schema struct {
+	Ref              string             `json:"$ref,omitempty"`
+	Type             string             `json:"type,omitempty"`
+	Format           string             `json:"format,omitempty"`
+	ContentMediaType string             `json:"contentMediaType,omitempty"`
+	Description      string             `json:"description,omitempty"`
+	Properties       map[string]*Schema `json:"properties,omitempty"`
+	Required         []string           `json:"required,omitempty"`
+	Items            *Schema            `json:"items,omitempty"`
+	OneOf            []*Schema          `json:"oneOf,omitempty"`
+	Enum             []string           `json:"enum,omitempty"`
+	Default          interface{}        `json:"default,omitempty"`
+	Minimum          *float64           `json:"minimum,omitempty"`
+	Maximum          *float64           `json:"maximum,omitempty"`
+	MaxItems         int                `json:"maxItems,omitempty"`
+}
+
+// Param is a path or query parameter.
+type Param struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Body is a request or response payload. ContentType defaults to JSON.
+type Body struct {
+	ContentType string
+	Schema      *Schema
+}
+
+// Response documents one status code an endpoint answers with.
+type Response struct {
+	Status      int
+	Description string
+	Body        *Body
+}
+
+const (
+	jsonContent      = "application/json"
+	multipartContent = "multipart/form-data"
+)
+
+// Describe sets the schema's description.
+func (s *Schema) Describe(description string) *Schema {
+	s.Description = description
+	return s
+}
+
+// Between sets inclusive bounds on a numeric schema.
+func (s *Schema) Between(min, max float64) *Schema {
+	s.Minimum, s.Maximum = &min, &max
+	return s
+}
+
+// AtLeast sets an inclusive lower bound on a numeric schema.
+func (s *Schema) AtLeast(min float64) *Schema {
+	s.Minimum = &min
+	return s
+}
+
+// Defaults sets the value assumed when the field is omitted.
+func (s *Schema) Defaults(value interface{}) *Schema {
+	s.Default = value
+	return s
+}
+
+func stringSchema() *Schema {
+	return &Schema{Type: "string"}
+}
+
+func integerSchema() *Schema {
+	return &Schema{Type: "integer"}
+}
+
+func numberSchema() *Schema {
+	return &Schema{Type: "number"}
+}
+
+func booleanSchema() *Schema {
+	return &Schema{Type: "boolean"}
+}
+
+func binarySchema() *Schema {
+	return &Schema{Type: "string", ContentMediaType: "application/octet-stream"}
+}
+
+func enumSchema(values ...string) *Schema {
+	return &Schema{Type: "string", Enum: values}
+}
+
+// freeformSchema accepts any JSON value, such as a metadata value.
+func freeformSchema() *Schema {
+	return &Schema{}
+}
+
+func arraySchema(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// objectSchema describes an object with the given properties, of which
+// the named ones are required.
+func objectSchema(props map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: props, Required: required}
+}
+
+// mapSchema is an object with arbitrary keys, such as metadata.
+func mapSchema() *Schema {
+	return &Schema{Type: "object"}
+}
+
+func refSchema(component string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + component}
+}
+
+func jsonBody(schema *Schema) *Body {
+	return &Body{ContentType: jsonContent, Schema: schema}
+}
+
+func queryParam(name, description string, schema *Schema) Param {
+	return Param{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func pathParam(name, description string) Param {
+	return Param{Name: name, In: "path", Description: description, Required: true, Schema: stringSchema()}
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"vector-database/httpinfo"
+)
+
+// AccessLog writes one line per request once it completes. Server errors
+// are logged at error level, everything else at info. It must run inside
+// requestid.Middleware, and inside tracing.Middleware for lines to carry
+// the trace id.
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := httpinfo.NewStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.Status),
+			slog.Int64("bytes", rec.Bytes),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote", r.RemoteAddr),
+		}
+		if ep, ok := httpinfo.Lookup(r.Method, r.URL.Path); ok {
+			attrs = append(attrs, slog.String("route", ep.Path))
+		}
+
+		level := slog.LevelInfo
+		if rec.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		FromContext(r.Context()).LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
@@ -0,0 +1,48 @@
+// Package logging configures the structured process logger and writes one
+// access log line per request.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"vector-database/config"
+	"vector-database/requestid"
+)
+
+// New builds the logger described by cfg. Install it with slog.SetDefault,
+// which also routes the standard log package through it.
+func New(cfg config.Logging) (*slog.Logger, error) {
+	var level slog.Level
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(strings.ToLower(cfg.Level))); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.Format == config.LogFormatJSON {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+	return slog.New(handler), nil
+}
+
+// FromContext returns the default logger annotated with the request id and
+// trace id of ctx, when present.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if id := requestid.FromContext(ctx); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		logger = logger.With("trace_id", sc.TraceID().String())
+	}
+	return logger
+}
@@ -2,52 +2,317 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 	"time"
 
+	"vector-database/analysis"
+	"vector-database/auth"
 	"vector-database/config"
 	"vector-database/db"
+	"vector-database/db/embeddingcache"
 	"vector-database/handler"
 	"vector-database/httpinfo"
+	"vector-database/logging"
+	"vector-database/metrics"
+	"vector-database/ratelimit"
+	"vector-database/requestid"
 	"vector-database/service"
+	"vector-database/tracing"
 )
 
+// version is stamped at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+// startupTimeout bounds connecting to Mongo and loading encoder state.
+const startupTimeout = 30 * time.Second
+
 func main() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("load config", err)
+	}
+
+	logger, err := logging.New(cfg.Logging)
+	if err != nil {
+		fatal("init logging", err)
+	}
+	slog.SetDefault(logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
 	defer cancel()
 
-	cfg, err := config.Load()
+	database, err := db.New(ctx, cfg)
 	if err != nil {
-		log.Fatalf("load config: %v", err)
+		fatal("init mongo vector store", err)
 	}
 
-	database, err := db.New(ctx, cfg.MongoDB)
+	analyzer, err := analysis.New(cfg.Encoder.Analyzer)
 	if err != nil {
-		log.Fatalf("init mongo vector store: %v", err)
+		fatal("init analyzer", err)
 	}
-	defer func() {
-		_ = database.Close(context.Background())
-	}()
 
-	encoder, err := service.NewEncoder(cfg.MongoDB.EmbeddingDimension)
+	encoder, bareEncoder, err := newEncoder(ctx, cfg, database, analyzer)
 	if err != nil {
-		log.Fatalf("init encoder: %v", err)
+		fatal("init encoder", err)
 	}
 
-	embeddingService, err := service.NewSearch(database.Documents, encoder, cfg.MongoDB.EmbeddingDimension)
+	batch := service.BatchOptions{
+		Size:        cfg.Encoder.Batch.Size,
+		Concurrency: cfg.Encoder.Batch.Concurrency,
+		MaxRetries:  cfg.Encoder.Batch.MaxRetries,
+	}
+	embeddingService, err := service.NewSearch(database.Documents, encoder, cfg.MongoDB.EmbeddingDimension, batch, newReranker(cfg.Rerank, analyzer))
 	if err != nil {
-		log.Fatalf("init embedding service: %v", err)
+		fatal("init embedding service", err)
 	}
 
 	messageHandler := handler.NewMessageHandler(embeddingService)
 	imageHandler := handler.NewImageHandler(embeddingService)
+	adminHandler := handler.NewAdminHandler(embeddingService)
+	keyring := auth.NewKeyring(cfg.Auth, database.APIKeys)
+	keyHandler := handler.NewKeyHandler(keyring)
+	var mongoPinger service.Pinger
+	if database.Connected() {
+		mongoPinger = database
+	}
+	healthHandler := handler.NewHealthHandler(service.NewHealth(mongoPinger, database.Documents, bareEncoder, cfg.MongoDB.EmbeddingDimension, version))
+	openAPIHandler, err := handler.NewOpenAPIHandler(version)
+	if err != nil {
+		fatal("init openapi document", err)
+	}
 	mux := http.NewServeMux()
-	messageHandler.Register(mux)
-	imageHandler.Register(mux)
+	handler.Mount(mux, slices.Concat(
+		messageHandler.Routes(),
+		imageHandler.Routes(),
+		adminHandler.Routes(),
+		keyHandler.Routes(),
+		healthHandler.Routes(),
+	)...)
+
+	var root http.Handler = handler.Unmatched(mux)
+	if cfg.RateLimit.Enabled {
+		limit, err := ratelimit.Middleware(cfg.RateLimit, database.Quotas)
+		if err != nil {
+			fatal("init rate limiting", err)
+		}
+		root = limit(root)
+	}
+	if cfg.Auth.Enabled {
+		root = auth.Middleware(keyring, httpinfo.ScopeFor)(root)
+	}
+	if cfg.RateLimit.Enabled && cfg.RateLimit.IP.Rate > 0 {
+		limitIP, err := ratelimit.IPMiddleware(cfg.RateLimit)
+		if err != nil {
+			fatal("init rate limiting", err)
+		}
+		root = limitIP(root)
+	}
+	root = logging.AccessLog(root)
+	if cfg.Tracing.Enabled() {
+		if err := tracing.Init(cfg.Tracing); err != nil {
+			fatal("init tracing", err)
+		}
+		root = tracing.Middleware(root)
+	}
+
+	probes := http.NewServeMux()
+	handler.Mount(probes, slices.Concat(
+		healthHandler.ProbeRoutes(),
+		openAPIHandler.Routes(),
+		[]handler.Route{{Endpoint: httpinfo.MetricsEndpoint, Handle: metrics.Handler().ServeHTTP}},
+	)...)
+	// Everything else goes to root, whose Unmatched answers unknown routes.
+	probes.Handle("/", root)
+
+	cancel()
+
+	server := newServer(cfg.Server, requestid.Middleware(metrics.Middleware(probes)))
+	if err := run(server, cfg.Server, database); err != nil {
+		fatal("serve", err)
+	}
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// run serves until the listener fails or SIGINT/SIGTERM arrives, then
+// drains in-flight requests, closes the database and flushes spans.
+func run(server *http.Server, cfg config.Server, database *db.Database) error {
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP server listening", "addr", server.Addr, "tls", cfg.TLS.Enabled(), "version", version)
+		if cfg.TLS.Enabled() {
+			serveErr <- server.ListenAndServeTLS(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			serveErr <- server.ListenAndServe()
+		}
+	}()
+
+	var runErr error
+	select {
+	case err := <-serveErr:
+		runErr = err
+	case <-signals.Done():
+		slog.Info("shutting down, draining requests", "timeout", shutdownTimeout(cfg))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout(cfg))
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("drain requests", "error", err)
+	}
+	if err := database.Close(ctx); err != nil {
+		slog.Error("close database", "error", err)
+	}
+	if err := tracing.Shutdown(ctx); err != nil {
+		slog.Error("flush spans", "error", err)
+	}
+	return runErr
+}
+
+// newServer applies the server section, falling back to conservative
+// timeouts so slow clients cannot hold connections forever.
+func newServer(cfg config.Server, handler http.Handler) *http.Server {
+	addr := cfg.Addr
+	if addr == "" {
+		addr = httpinfo.DefaultAddr
+	}
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       orDefault(cfg.ReadTimeout, 30*time.Second),
+		ReadHeaderTimeout: orDefault(cfg.ReadHeaderTimeout, 10*time.Second),
+		WriteTimeout:      orDefault(cfg.WriteTimeout, 60*time.Second),
+		IdleTimeout:       orDefault(cfg.IdleTimeout, 120*time.Second),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+func shutdownTimeout(cfg config.Server) time.Duration {
+	return orDefault(cfg.ShutdownTimeout, 30*time.Second)
+}
+
+func orDefault(value, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// newEncoder builds the configured encoder, instrumented and cached, and
+// also returns the bare encoder underneath for the readiness self-test, so
+// probes neither hit the cache nor count in the encoder metrics.
+func newEncoder(ctx context.Context, cfg config.Configs, database *db.Database, analyzer analysis.Analyzer) (service.EncoderService, service.EncoderService, error) {
+	var (
+		encoder service.EncoderService
+		err     error
+	)
+	switch cfg.Encoder.Type {
+	case config.EncoderTFIDF:
+		encoder, err = service.NewTFIDFEncoder(ctx, cfg.MongoDB.EmbeddingDimension, analyzer, database.Corpus)
+	case config.EncoderStatic:
+		// Stems such as "compani" are not in word vector vocabularies, so
+		// lookups use the configured chain without its stemmer.
+		lookup := cfg.Encoder.Analyzer
+		lookup.Stemmer = ""
+		if analyzer, err = analysis.New(lookup); err != nil {
+			return nil, nil, err
+		}
+		encoder, err = service.NewStaticEncoder(cfg.MongoDB.EmbeddingDimension, analyzer, service.StaticOptions{
+			Path:          cfg.Encoder.Static.Path,
+			SIFWeight:     cfg.Encoder.Static.SIFWeight,
+			MaxWords:      cfg.Encoder.Static.MaxWords,
+			PCSampleWords: cfg.Encoder.Static.PCSampleWords,
+		})
+	default:
+		encoder, err = service.NewEncoder(cfg.MongoDB.EmbeddingDimension, analyzer)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	bare := encoder
+	encoder = service.InstrumentEncoder(encoder, encoderType(cfg))
+	if !cfg.Encoder.Cache.Enabled() {
+		return encoder, bare, nil
+	}
+	if _, ok := encoder.(service.CorpusObserver); ok {
+		slog.Warn("embedding cache disabled: the encoder's weights change as documents are indexed", "encoder", encoderType(cfg))
+		return encoder, bare, nil
+	}
+
+	var persistent embeddingcache.Store
+	if cfg.Encoder.Cache.Persistent {
+		persistent = database.EmbeddingCache
+	}
+	return service.NewCachedEncoder(encoder, encoderIdentity(cfg), cfg.Encoder.Cache.Size, persistent), bare, nil
+}
+
+// encoderIdentity names everything that influences the produced vectors so
+// cached embeddings are never shared across incompatible encoder setups.
+func encoderIdentity(cfg config.Configs) string {
+	encoderType := encoderType(cfg)
+	a := cfg.Encoder.Analyzer
+	identity := fmt.Sprintf("%s/dim=%d/tokenizer=%s/stopWords=%s/stemmer=%s/ngram=%d",
+		encoderType, cfg.MongoDB.EmbeddingDimension, a.Tokenizer, a.StopWords, a.Stemmer, a.NGramSize)
+	if encoderType == config.EncoderStatic {
+		st := cfg.Encoder.Static
+		identity += fmt.Sprintf("/vectors=%s/sif=%g/maxWords=%d/pc=%d", st.Path, st.SIFWeight, st.MaxWords, st.PCSampleWords)
+	}
+	return identity
+}
+
+func encoderType(cfg config.Configs) string {
+	if cfg.Encoder.Type == "" {
+		return config.EncoderHash
+	}
+	return cfg.Encoder.Type
+}
+
+// newReranker builds the configured rerank stages, or returns nil when none
+// are configured.
+func newReranker(cfg config.Rerank, analyzer analysis.Analyzer) service.Reranker {
+	if len(cfg.Stages) == 0 {
+		return nil
+	}
 
-	log.Printf("HTTP server listening on %s", httpinfo.DefaultAddr)
-	if err := http.ListenAndServe(httpinfo.DefaultAddr, mux); err != nil {
-		log.Fatalf("server error: %v", err)
+	stages := make([]service.Reranker, 0, len(cfg.Stages))
+	for _, stage := range cfg.Stages {
+		switch stage {
+		case config.RerankHTTPStage:
+			stages = append(stages, service.NewHTTPReranker(service.HTTPRerankOptions{
+				URL:     cfg.HTTP.URL,
+				APIKey:  cfg.HTTP.APIKey,
+				Model:   cfg.HTTP.Model,
+				Timeout: cfg.HTTP.Timeout,
+			}, nil))
+		case config.RerankLexicalStage:
+			stages = append(stages, service.NewLexicalReranker(analyzer, cfg.Lexical.Weight))
+		case config.RerankMetadataStage:
+			rules := make([]service.MetadataBoostRule, len(cfg.Metadata.Rules))
+			for i, rule := range cfg.Metadata.Rules {
+				rules[i] = service.MetadataBoostRule{
+					Field:    rule.Field,
+					Equals:   rule.Equals,
+					Boost:    rule.Boost,
+					HalfLife: rule.HalfLife,
+					Weight:   rule.Weight,
+				}
+			}
+			stages = append(stages, service.NewMetadataReranker(rules))
+		}
 	}
+	return service.NewRerankChain(stages...)
 }
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"vector-database/httpinfo"
+)
+
+// unmatched labels requests that no endpoint serves, keeping label
+// cardinality bounded whatever paths clients send.
+const unmatched = "unmatched"
+
+var (
+	httpRequests = NewCounterVec(
+		"http_requests_total",
+		"HTTP requests served, by endpoint, method and status code.",
+		"endpoint", "method", "status",
+	)
+	httpDuration = NewHistogramVec(
+		"http_request_duration_seconds",
+		"HTTP request latency, by endpoint and method.",
+		LatencyBuckets,
+		"endpoint", "method",
+	)
+	httpErrors = NewCounterVec(
+		"http_errors_total",
+		"HTTP requests that failed, by endpoint and error class.",
+		"endpoint", "class",
+	)
+)
+
+// Middleware records request counts, latency and errors per
+// httpinfo.Endpoint. The error class is the code of the error body, or the
+// code the status implies when the body was not written by
+// httpinfo.WriteError. It should wrap auth and rate limiting so rejected
+// requests are counted too.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint, method := unmatched, unmatched
+		if ep, ok := httpinfo.Lookup(r.Method, r.URL.Path); ok {
+			endpoint, method = ep.Path, ep.Method
+		}
+
+		start := time.Now()
+		rec := httpinfo.NewStatusRecorder(w)
+		ctx, code := httpinfo.RecordCode(r.Context())
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		httpDuration.ObserveSince(start, endpoint, method)
+		httpRequests.Inc(endpoint, method, strconv.Itoa(rec.Status))
+		class := code()
+		if class == "" {
+			class = httpinfo.CodeForStatus(rec.Status)
+		}
+		if class != "" {
+			httpErrors.Inc(endpoint, class)
+		}
+	})
+}
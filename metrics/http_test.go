@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"vector-database/httpinfo"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("scrape status = %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func TestMiddlewareRecordsErrorCode(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/messages/search":
+			httpinfo.WriteError(w, r, http.StatusBadRequest, httpinfo.APIError{Code: httpinfo.CodeDimensionMismatch, Message: "wrong dimension"})
+		case "/api/admin/index/train":
+			w.WriteHeader(http.StatusTeapot)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/api/messages/search", nil),
+		httptest.NewRequest(http.MethodPost, "/api/admin/index/train", nil),
+		httptest.NewRequest(http.MethodPost, "/api/messages", nil),
+		httptest.NewRequest(http.MethodGet, "/nowhere", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	body := scrape(t)
+	for _, want := range []string{
+		`http_requests_total{endpoint="/api/messages/search",method="POST",status="400"} 1`,
+		`http_errors_total{endpoint="/api/messages/search",class="dimension_mismatch"} 1`,
+		`http_errors_total{endpoint="/api/admin/index/train",class="client_error"} 1`,
+		`http_requests_total{endpoint="/api/messages",method="POST",status="200"} 1`,
+		`http_requests_total{endpoint="unmatched",method="unmatched",status="200"} 1`,
+		`http_request_duration_seconds_count{endpoint="/api/messages/search",method="POST"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+	if strings.Contains(body, `class="invalid_argument"`) {
+		t.Errorf("dimension mismatch was counted as invalid_argument")
+	}
+	if strings.Contains(body, `http_errors_total{endpoint="/api/messages",`) {
+		t.Errorf("successful request was counted as an error")
+	}
+}
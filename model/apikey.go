@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey is a stored API key. Only the SHA-256 hash of the secret is kept.
+type APIKey struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	Hash      string             `bson:"hash" json:"-"`
+	Scopes    []string           `bson:"scopes" json:"scopes"`
+	Tenant    string             `bson:"tenant" json:"tenant"`
+	CreatedBy string             `bson:"createdBy,omitempty" json:"created_by,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"created_at"`
+}
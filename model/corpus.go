@@ -0,0 +1,7 @@
+package model
+
+// CorpusStats holds the document frequencies used to compute IDF weights.
+type CorpusStats struct {
+	Documents         int64
+	DocumentFrequency map[string]int64
+}
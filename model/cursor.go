@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Cursor marks the last result of a page. Results are ordered by descending
+// score and then ascending id, so (Score, ID) identifies a stable position;
+// Depth is how many results have been returned so far and sizes the
+// candidate window of the next page.
+type Cursor struct {
+	Score float64
+	ID    string
+	Depth int
+}
+
+// Encode returns the opaque token handed to clients as next_cursor.
+func (c Cursor) Encode() string {
+	raw := strconv.FormatFloat(c.Score, 'g', -1, 64) + ":" + c.ID + ":" + strconv.Itoa(c.Depth)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor decodes a token produced by Encode.
+func ParseCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("cursor is malformed")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 {
+		return nil, errors.New("cursor is malformed")
+	}
+
+	score, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("cursor score is malformed")
+	}
+	depth, err := strconv.Atoi(parts[2])
+	if err != nil || depth < 0 {
+		return nil, fmt.Errorf("cursor depth is malformed")
+	}
+	if depth > MaxResultWindow {
+		return nil, fmt.Errorf("cursor depth must not exceed %d", MaxResultWindow)
+	}
+	return &Cursor{Score: score, ID: parts[1], Depth: depth}, nil
+}
+
+// Before reports whether a result with the given score and id sorts at or
+// before the cursor position, i.e. was already returned.
+func (c Cursor) Before(score float64, id string) bool {
+	if score != c.Score {
+		return score > c.Score
+	}
+	return id <= c.ID
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"encoding/base64"
+	"strconv"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := Cursor{Score: 0.8123456789, ID: "65f0c1e2a3b4c5d6e7f80912", Depth: 40}
+	got, err := ParseCursor(want.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+}
+
+func TestParseCursorRejectsMalformedTokens(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	tokens := map[string]string{
+		"not base64":     "!!!",
+		"missing parts":  encode("0.5:abc"),
+		"bad score":      encode("high:abc:1"),
+		"negative depth": encode("0.5:abc:-1"),
+		"overflow depth": encode("0.5:abc:99999999999999999999"),
+		"too deep":       encode("0.5:abc:" + strconv.Itoa(MaxResultWindow+1)),
+	}
+	for name, token := range tokens {
+		if _, err := ParseCursor(token); err == nil {
+			t.Errorf("%s: ParseCursor(%q) succeeded", name, token)
+		}
+	}
+}
+
+func TestCursorBefore(t *testing.T) {
+	c := Cursor{Score: 0.5, ID: "b"}
+	cases := []struct {
+		score float64
+		id    string
+		want  bool
+	}{
+		{0.6, "z", true},
+		{0.5, "a", true},
+		{0.5, "b", true},
+		{0.5, "c", false},
+		{0.4, "a", false},
+	}
+	for _, tc := range cases {
+		if got := c.Before(tc.score, tc.id); got != tc.want {
+			t.Errorf("Before(%v, %q) = %v, want %v", tc.score, tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestValidateBoundsTheResultWindow(t *testing.T) {
+	vector := []float32{1, 0}
+	cases := []struct {
+		name  string
+		query VectorQuery
+		ok    bool
+	}{
+		{"within window", VectorQuery{QueryVector: vector, Limit: 10, Offset: MaxResultWindow - 10}, true},
+		{"offset past window", VectorQuery{QueryVector: vector, Limit: 10, Offset: MaxResultWindow - 9}, false},
+		{"cursor past window", VectorQuery{QueryVector: vector, Limit: 1, After: &Cursor{Depth: MaxResultWindow}}, false},
+		{"huge limit", VectorQuery{QueryVector: vector, Limit: int(^uint(0) >> 1)}, false},
+		{"huge cursor", VectorQuery{QueryVector: vector, Limit: 10, After: &Cursor{Depth: int(^uint(0) >> 1)}}, false},
+		{"too many candidates", VectorQuery{QueryVector: vector, Limit: 10, NumCandidates: MaxCandidates + 1}, false},
+	}
+	for _, tc := range cases {
+		err := tc.query.Validate(len(vector))
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", tc.name, err, tc.ok)
+		}
+		if err == nil && tc.query.Candidates() > MaxCandidates {
+			t.Errorf("%s: %d candidates exceed %d", tc.name, tc.query.Candidates(), MaxCandidates)
+		}
+	}
+}
+
+func TestNextCursorStopsAtResultWindow(t *testing.T) {
+	opts := SearchOptions{Limit: 10, Cursor: &Cursor{Depth: MaxResultWindow - 20}}
+	if opts.NextCursor(10, 0.5, "a") == "" {
+		t.Error("no cursor for a full page inside the window")
+	}
+	opts.Cursor.Depth = MaxResultWindow - 10
+	if token := opts.NextCursor(10, 0.5, "a"); token != "" {
+		t.Errorf("cursor %q for a page ending at the window", token)
+	}
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Validate() = %v for the last page", err)
+	}
+}
+
+func TestFetchLimitOversamplesExactQueries(t *testing.T) {
+	cases := []struct {
+		name       string
+		query      VectorQuery
+		fetch      int
+		candidates int
+	}{
+		{"approximate", VectorQuery{Limit: 10, NumCandidates: 10}, 10, 10},
+		{"exact", VectorQuery{Limit: 10, NumCandidates: 10, Exact: true}, 10 * RescoreOversample, 10 * RescoreOversample},
+		{"exact offset", VectorQuery{Limit: 10, Offset: 5, Exact: true}, 15 * RescoreOversample, 15 * 5},
+		{"exact cursor", VectorQuery{Limit: 10, After: &Cursor{Depth: 20}, Exact: true}, 30 * RescoreOversample, 30 * 5},
+		{"more candidates", VectorQuery{Limit: 10, NumCandidates: 100, Exact: true}, 10 * RescoreOversample, 100},
+	}
+	for _, tc := range cases {
+		if got := tc.query.FetchLimit(); got != tc.fetch {
+			t.Errorf("%s: FetchLimit() = %d, want %d", tc.name, got, tc.fetch)
+		}
+		if got := tc.query.Candidates(); got != tc.candidates {
+			t.Errorf("%s: Candidates() = %d, want %d", tc.name, got, tc.candidates)
+		}
+	}
+}
@@ -13,6 +13,7 @@ type Document struct {
 	Embedding []float32              `bson:"embedding" json:"embedding"`
 	Metadata  map[string]interface{} `bson:"metadata,omitempty" json:"metadata,omitempty"`
 	Score     float64                `bson:"score,omitempty" json:"score,omitempty"`
+	Tenant    string                 `bson:"tenant,omitempty" json:"-"`
 }
 
 // DocumentInput is the data provided by callers before an embedding is generated.
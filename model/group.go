@@ -0,0 +1,132 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultGroupSize is how many results each group keeps by default.
+	DefaultGroupSize = 3
+	// GroupOversample sizes the candidate set that groups and facet counts
+	// are computed over, relative to the number of results requested.
+	GroupOversample = 10
+)
+
+// GroupQuery searches by text and buckets the results by a metadata field.
+// Options.Limit is the number of groups returned; PerGroup is the top-k kept
+// within each of them.
+type GroupQuery struct {
+	Text     string
+	Field    string
+	PerGroup int
+	Options  SearchOptions
+}
+
+// Validate checks the field is a metadata path and the options are ones
+// grouping supports.
+func (q GroupQuery) Validate() error {
+	key, ok := strings.CutPrefix(q.Field, "metadata.")
+	if !ok || key == "" || strings.ContainsAny(key, "$") {
+		return fmt.Errorf("group_by must name a metadata field such as metadata.topic, got %q", q.Field)
+	}
+	if q.PerGroup <= 0 {
+		return errors.New("group size must be positive")
+	}
+	if q.PerGroup > MaxResultWindow || q.Options.Limit > MaxResultWindow/q.PerGroup {
+		return fmt.Errorf("limit times group size must not exceed %d", MaxResultWindow)
+	}
+	if q.Options.Offset > 0 || q.Options.Cursor != nil || q.Options.MMR || q.Options.Rerank {
+		return errors.New("group_by cannot be combined with offset, cursor, mmr or rerank")
+	}
+	return nil
+}
+
+// Window is the size of the candidate set grouped over, at most
+// MaxResultWindow.
+func (q GroupQuery) Window() int {
+	return min(q.Options.Limit*q.PerGroup*GroupOversample, MaxResultWindow)
+}
+
+// Group holds the best results sharing one value of the grouped field and
+// how many candidates had that value.
+type Group struct {
+	Value     interface{}
+	Count     int
+	Documents []Document
+}
+
+// Facet counts the candidates that share one value of the grouped field.
+type Facet struct {
+	Value interface{}
+	Count int
+}
+
+// GroupedResults are the top groups plus facet counts over every candidate.
+type GroupedResults struct {
+	Groups []Group
+	Facets []Facet
+}
+
+// GroupDocuments buckets docs, sorted by descending score and then
+// ascending id, on a metadata field. Groups are ordered by their best
+// document, that is by best score and then by that document's id. Facets
+// are ordered by count, ties broken the same way. Documents without the
+// field share a nil group.
+func GroupDocuments(docs []Document, field string, groups, perGroup int) GroupedResults {
+	path := strings.Split(strings.TrimPrefix(field, "metadata."), ".")
+
+	var all []Group
+	index := map[string]int{}
+	for _, doc := range docs {
+		value := lookupMetadata(doc.Metadata, path)
+		key := groupKey(value)
+		i, ok := index[key]
+		if !ok {
+			i = len(all)
+			index[key] = i
+			all = append(all, Group{Value: value})
+		}
+		all[i].Count++
+		if len(all[i].Documents) < perGroup {
+			all[i].Documents = append(all[i].Documents, doc)
+		}
+	}
+
+	facets := make([]Facet, len(all))
+	for i, group := range all {
+		facets[i] = Facet{Value: group.Value, Count: group.Count}
+	}
+	sort.SliceStable(facets, func(i, j int) bool {
+		return facets[i].Count > facets[j].Count
+	})
+
+	if len(all) > groups {
+		all = all[:groups]
+	}
+	return GroupedResults{Groups: all, Facets: facets}
+}
+
+func lookupMetadata(metadata map[string]interface{}, path []string) interface{} {
+	var current interface{} = metadata
+	for _, part := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	return current
+}
+
+// groupKey makes values of any JSON shape usable as map keys.
+func groupKey(value interface{}) string {
+	key, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%T:%v", value, value)
+	}
+	return string(key)
+}
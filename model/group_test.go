@@ -0,0 +1,66 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGroupDocumentsOrdersGroupsAndFacets(t *testing.T) {
+	ids := make([]primitive.ObjectID, 5)
+	for i := range ids {
+		ids[i][11] = byte(i)
+	}
+	doc := func(i int, score float64, topic interface{}) Document {
+		metadata := map[string]interface{}{}
+		if topic != nil {
+			metadata["topic"] = topic
+		}
+		return Document{ID: ids[i], Score: score, Metadata: metadata}
+	}
+	// Sorted by descending score, then ascending id, as stores return them.
+	docs := []Document{
+		doc(0, 0.9, "b"),
+		doc(1, 0.9, "a"),
+		doc(2, 0.8, "a"),
+		doc(3, 0.7, nil),
+		doc(4, 0.6, "b"),
+	}
+
+	got := GroupDocuments(docs, "metadata.topic", 2, 1)
+	var values []interface{}
+	for _, group := range got.Groups {
+		values = append(values, group.Value)
+	}
+	if !reflect.DeepEqual(values, []interface{}{"b", "a"}) {
+		t.Errorf("groups %v, want [b a]", values)
+	}
+	if len(got.Groups[1].Documents) != 1 || got.Groups[1].Documents[0].ID != ids[1] {
+		t.Errorf("group a kept %v, want only its best document", got.Groups[1].Documents)
+	}
+
+	want := []Facet{{Value: "b", Count: 2}, {Value: "a", Count: 2}, {Value: nil, Count: 1}}
+	if !reflect.DeepEqual(got.Facets, want) {
+		t.Errorf("facets %v, want %v", got.Facets, want)
+	}
+}
+
+func TestGroupQueryWindowIsCapped(t *testing.T) {
+	query := GroupQuery{Field: "metadata.topic", PerGroup: 100, Options: SearchOptions{Limit: 20}}
+	if err := query.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+	if window := query.Window(); window != MaxResultWindow {
+		t.Errorf("Window() = %d, want %d", window, MaxResultWindow)
+	}
+
+	query.Options.Limit = 21
+	if err := query.Validate(); err == nil {
+		t.Error("accepted limit times group size above the window")
+	}
+	query.PerGroup = int(^uint(0) >> 1)
+	if err := query.Validate(); err == nil {
+		t.Error("accepted an overflowing group size")
+	}
+}
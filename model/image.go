@@ -27,7 +27,7 @@ func (i ImageInput) Validate() error {
 type ImageQuery struct {
 	ImageData   []byte
 	Description string
-	Limit       int
+	Options     SearchOptions
 }
 
 // Validate ensures the query can be executed safely.
@@ -35,7 +35,7 @@ func (q ImageQuery) Validate() error {
 	if len(q.ImageData) == 0 {
 		return errors.New("image is required")
 	}
-	if q.Limit < 0 {
+	if q.Options.Limit < 0 {
 		return errors.New("limit must be a positive integer")
 	}
 	return nil
@@ -0,0 +1,23 @@
+package model
+
+// IndexInfo describes a trained approximate nearest neighbour index.
+type IndexInfo struct {
+	Type       string `json:"type"`
+	Lists      int    `json:"lists"`
+	SubVectors int    `json:"sub_vectors"`
+	Vectors    int    `json:"vectors"`
+}
+
+// IndexStatus reports whether the vector index can serve queries.
+type IndexStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Queryable bool   `json:"queryable"`
+}
+
+// StoreStatus summarises a document store for operators.
+type StoreStatus struct {
+	Type      string      `json:"type"`
+	Documents int64       `json:"documents"`
+	Index     IndexStatus `json:"index"`
+}
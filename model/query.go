@@ -5,12 +5,110 @@ import (
 	"fmt"
 )
 
+// RescoreOversample is how many more candidates than Limit an exact search
+// fetches before rescoring.
+const RescoreOversample = 4
+
+// MaxResultWindow bounds how deep a search may page: offset or cursor
+// depth plus limit. Candidates asks for five per result, which keeps it
+// within Atlas's limit of 10000 numCandidates.
+const MaxResultWindow = 2000
+
+// MaxCandidates is the most candidates a query may consider.
+const MaxCandidates = MaxResultWindow * 5
+
 // VectorQuery describes a similarity search request.
 type VectorQuery struct {
 	QueryVector   []float32
 	Limit         int
 	NumCandidates int
-	Filter        map[string]interface{}
+	// Exact fetches an oversampled candidate set and recomputes exact
+	// cosine scores from the stored embeddings before trimming to Limit.
+	Exact bool
+	// MinScore drops results scoring below it.
+	MinScore float64
+	// Offset skips that many results; After resumes after a cursor. At
+	// most one of them may be set.
+	Offset int
+	After  *Cursor
+}
+
+// DefaultMMRLambda balances relevance and diversity equally.
+const DefaultMMRLambda = 0.5
+
+// SearchOptions tunes a search beyond the query text itself.
+type SearchOptions struct {
+	Limit    int
+	Exact    bool
+	MinScore float64
+	Offset   int
+	Cursor   *Cursor
+	// MMR re-ranks results with maximal marginal relevance. MMRLambda
+	// weighs relevance (1) against diversity (0); nil uses DefaultMMRLambda.
+	MMR       bool
+	MMRLambda *float64
+	// Rerank passes the candidates through the configured reranker.
+	Rerank bool
+}
+
+// Lambda returns the MMR trade-off to use.
+func (o SearchOptions) Lambda() float64 {
+	if o.MMRLambda != nil {
+		return *o.MMRLambda
+	}
+	return DefaultMMRLambda
+}
+
+// Validate checks the options that are not covered by VectorQuery.Validate.
+func (o SearchOptions) Validate() error {
+	if o.MMRLambda != nil && (*o.MMRLambda < 0 || *o.MMRLambda > 1) {
+		return errors.New("lambda must be between 0 and 1")
+	}
+	if (o.MMR || o.Rerank) && o.Cursor != nil {
+		return errors.New("cursor paging is not available with mmr or rerank, use offset")
+	}
+	return checkWindow(o.Offset, o.Depth(), o.Limit)
+}
+
+// checkWindow rejects pages that end beyond MaxResultWindow. Checking the
+// parts before their sum keeps forged values from overflowing.
+func checkWindow(offset, depth, limit int) error {
+	if offset < 0 {
+		return errors.New("offset must not be negative")
+	}
+	if depth > MaxResultWindow || limit > MaxResultWindow-depth {
+		return fmt.Errorf("offset plus limit must not exceed %d", MaxResultWindow)
+	}
+	return nil
+}
+
+// Depth is the number of results that precede the requested page.
+func (o SearchOptions) Depth() int {
+	if o.Cursor != nil {
+		return o.Cursor.Depth
+	}
+	return o.Offset
+}
+
+// NextCursor returns the token for the page after one that returned count
+// results ending with (lastScore, lastID), or "" when the page was not full
+// or ends at MaxResultWindow. MMR and reranked results are not ordered by
+// retrieval score, so they have no cursor.
+func (o SearchOptions) NextCursor(count int, lastScore float64, lastID string) string {
+	if o.MMR || o.Rerank || count == 0 || count < o.Limit || o.Depth()+count >= MaxResultWindow {
+		return ""
+	}
+	return Cursor{Score: lastScore, ID: lastID, Depth: o.Depth() + count}.Encode()
+}
+
+// Apply copies the paging and scoring options onto a vector query.
+func (o SearchOptions) Apply(q VectorQuery) VectorQuery {
+	q.Limit = o.Limit
+	q.Exact = o.Exact
+	q.MinScore = o.MinScore
+	q.Offset = o.Offset
+	q.After = o.Cursor
+	return q
 }
 
 // Validate ensures the query has all information before it hits the db layer.
@@ -24,16 +122,74 @@ func (q VectorQuery) Validate(expectedDim int) error {
 	if q.NumCandidates != 0 && q.NumCandidates < q.Limit {
 		return errors.New("numCandidates must be >= limit")
 	}
-	return nil
+	if q.NumCandidates > MaxCandidates {
+		return fmt.Errorf("numCandidates must not exceed %d", MaxCandidates)
+	}
+	if q.Offset > 0 && q.After != nil {
+		return errors.New("offset and cursor cannot be combined")
+	}
+	return checkWindow(q.Offset, q.depth(), q.Limit)
 }
 
 // Candidates returns a safe default when the caller does not specify a value.
+// Queries never consider fewer candidates than they fetch.
 func (q VectorQuery) Candidates() int {
+	candidates := 50
 	if q.NumCandidates > 0 {
-		return q.NumCandidates
+		candidates = q.NumCandidates
+	} else if q.Limit > 0 {
+		candidates = (q.Limit + q.depth()) * 5
 	}
-	if q.Limit > 0 {
-		return q.Limit * 5
+	return max(candidates, q.FetchLimit())
+}
+
+// FetchLimit is how many results to retrieve from the index: everything up
+// to the end of the requested page, oversampled for exact queries.
+func (q VectorQuery) FetchLimit() int {
+	n := q.Limit + q.depth()
+	if q.Exact {
+		return n * RescoreOversample
 	}
-	return 50
+	return n
+}
+
+func (q VectorQuery) depth() int {
+	if q.After != nil {
+		return q.After.Depth
+	}
+	return q.Offset
+}
+
+// Page applies MinScore, the cursor or offset, and Limit to results already
+// sorted by descending score and ascending id.
+func (q VectorQuery) Page(docs []Document) []Document {
+	page := docs[:0]
+	skipped := 0
+	for _, doc := range docs {
+		if doc.Score < q.MinScore {
+			break
+		}
+		if q.After != nil && q.After.Before(doc.Score, doc.ID.Hex()) {
+			continue
+		}
+		if skipped < q.Offset {
+			skipped++
+			continue
+		}
+		page = append(page, doc)
+		if len(page) == q.Limit {
+			break
+		}
+	}
+	return page
+}
+
+// SimilarQuery asks for documents like an existing one. Positive and
+// Negative list further example document ids that pull the query vector
+// towards or away from them.
+type SimilarQuery struct {
+	ID       string
+	Positive []string
+	Negative []string
+	Options  SearchOptions
 }
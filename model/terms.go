@@ -0,0 +1,124 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WeightedTerm is one part of a composite query: either a text to encode or
+// a raw vector, scaled by Weight. Negative weights push results away.
+type WeightedTerm struct {
+	Text   string
+	Vector []float32
+	Weight float64
+}
+
+// HasText reports whether the term is a text term. Blank text counts as
+// none.
+func (t WeightedTerm) HasText() bool {
+	return strings.TrimSpace(t.Text) != ""
+}
+
+// TermQuery searches with the weighted sum of its terms.
+type TermQuery struct {
+	Terms   []WeightedTerm
+	Options SearchOptions
+}
+
+// Validate checks every term carries exactly one of text or vector and a
+// non-zero weight.
+func (q TermQuery) Validate() error {
+	if len(q.Terms) == 0 {
+		return errors.New("at least one query term is required")
+	}
+	for i, term := range q.Terms {
+		if term.HasText() == (len(term.Vector) > 0) {
+			return fmt.Errorf("term %d must have either text or a vector", i)
+		}
+		if term.Weight == 0 {
+			return fmt.Errorf("term %d has a zero weight", i)
+		}
+	}
+	return nil
+}
+
+// RerankText is the text second-stage rerankers score against: the
+// positively weighted text terms.
+func (q TermQuery) RerankText() string {
+	var texts []string
+	for _, term := range q.Terms {
+		if term.Weight > 0 && term.HasText() {
+			texts = append(texts, term.Text)
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+// ParseWeightedQuery parses a query such as
+//
+//	+0.7 "beach sunset" -0.3 people
+//
+// Each term is a quoted phrase or a single word, optionally preceded by a
+// sign and a weight. Unsigned terms and bare signs mean a weight of 1.
+func ParseWeightedQuery(raw string) ([]WeightedTerm, error) {
+	var terms []WeightedTerm
+	rest := strings.TrimSpace(raw)
+	for rest != "" {
+		weight := 1.0
+		if rest[0] == '+' || rest[0] == '-' {
+			if rest[0] == '-' {
+				weight = -1
+			}
+			rest = rest[1:]
+			if number, after, ok := leadingNumber(rest); ok {
+				weight *= number
+				rest = strings.TrimLeftFunc(after, unicode.IsSpace)
+			}
+		}
+
+		var text string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				return nil, errors.New("query has an unterminated quote")
+			}
+			text, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+		}
+
+		if text = strings.TrimSpace(text); text == "" {
+			return nil, errors.New("query has an empty term")
+		}
+		terms = append(terms, WeightedTerm{Text: text, Weight: weight})
+		rest = strings.TrimSpace(rest)
+	}
+
+	if len(terms) == 0 {
+		return nil, errors.New("query is empty")
+	}
+	return terms, nil
+}
+
+// leadingNumber reads a weight that is followed by whitespace or a quote, so
+// words that merely start with digits remain terms.
+func leadingNumber(s string) (float64, string, bool) {
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '"'
+	})
+	if end <= 0 {
+		return 0, s, false
+	}
+	number, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return 0, s, false
+	}
+	return number, s[end:], true
+}
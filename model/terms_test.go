@@ -0,0 +1,43 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWeightedQuery(t *testing.T) {
+	got, err := ParseWeightedQuery(`+0.7 "beach sunset" -0.3 people 3d -"night sky"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WeightedTerm{
+		{Text: "beach sunset", Weight: 0.7},
+		{Text: "people", Weight: -0.3},
+		{Text: "3d", Weight: 1},
+		{Text: "night sky", Weight: -1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	for _, raw := range []string{"", `"open`, `+0.5 "  "`} {
+		if _, err := ParseWeightedQuery(raw); err == nil {
+			t.Errorf("ParseWeightedQuery(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestTermQueryTreatsBlankTextAsNone(t *testing.T) {
+	query := TermQuery{Terms: []WeightedTerm{{Text: "  ", Vector: []float32{1}, Weight: 1}}}
+	if err := query.Validate(); err != nil {
+		t.Errorf("blank text beside a vector rejected: %v", err)
+	}
+	if text := query.RerankText(); text != "" {
+		t.Errorf("RerankText() = %q, want empty", text)
+	}
+
+	query = TermQuery{Terms: []WeightedTerm{{Text: " ", Weight: 1}}}
+	if err := query.Validate(); err == nil {
+		t.Error("blank text term without a vector accepted")
+	}
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := parseProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		want      string
+	}{
+		{"direct", "203.0.113.7:1234", nil, "203.0.113.7"},
+		{"untrusted peer", "203.0.113.7:1234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed left hops", "10.1.2.3:443", []string{"1.1.1.1, 198.51.100.1"}, "198.51.100.1"},
+		{"proxy chain", "10.1.2.3:443", []string{"198.51.100.1, 192.0.2.1", "10.9.9.9"}, "198.51.100.1"},
+		{"only proxies", "10.1.2.3:443", []string{"10.0.0.5"}, "10.0.0.5"},
+		{"no header", "10.1.2.3:443", nil, "10.1.2.3"},
+		{"ipv6 peer", "[2001:db8::1]:80", []string{"198.51.100.1"}, "2001:db8::1"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.peer
+		for _, value := range tc.forwarded {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+		if got := clientIP(r, proxies); got != tc.want {
+			t.Errorf("%s: clientIP() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestParseProxiesRejectsInvalidEntries(t *testing.T) {
+	if _, err := parseProxies([]string{"10.0.0.0/8", "proxy.internal"}); err == nil {
+		t.Error("accepted a host name")
+	}
+}
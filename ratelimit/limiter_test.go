@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter() (*Limiter, *time.Time) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLimiter()
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiterAllowsBurstThenRefills(t *testing.T) {
+	l, now := newTestLimiter()
+	rule := Rule{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("client", rule); !ok {
+			t.Fatalf("request %d of the burst rejected", i+1)
+		}
+	}
+	ok, wait := l.Allow("client", rule)
+	if ok {
+		t.Fatal("request beyond the burst allowed")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("wait = %v, want 500ms at 2 tokens per second", wait)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("client", rule); !ok {
+		t.Error("request rejected after a token refilled")
+	}
+	if ok, _ := l.Allow("client", rule); ok {
+		t.Error("second request allowed with only one token refilled")
+	}
+}
+
+func TestLimiterKeepsClientsApart(t *testing.T) {
+	l, _ := newTestLimiter()
+	rule := Rule{Rate: 1, Burst: 1}
+
+	if ok, _ := l.Allow("a", rule); !ok {
+		t.Fatal("first request of a rejected")
+	}
+	if ok, _ := l.Allow("a", rule); ok {
+		t.Error("second request of a allowed")
+	}
+	if ok, _ := l.Allow("b", rule); !ok {
+		t.Error("b throttled by a's bucket")
+	}
+}
+
+func TestLimiterDefaultsAndDisabledRules(t *testing.T) {
+	l, _ := newTestLimiter()
+
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow("client", Rule{}); !ok {
+			t.Fatal("zero rate throttled a request")
+		}
+	}
+	// Without a burst the bucket holds one second of tokens.
+	rule := Rule{Rate: 2.5}
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("fractional", rule); !ok {
+			t.Fatalf("request %d rejected, want a capacity of 3", i+1)
+		}
+	}
+	if ok, _ := l.Allow("fractional", rule); ok {
+		t.Error("fourth request allowed")
+	}
+}
+
+func TestLimiterSweepsFullBuckets(t *testing.T) {
+	l, now := newTestLimiter()
+	rule := Rule{Rate: 1, Burst: 1}
+	l.Allow("idle", rule)
+
+	*now = now.Add(sweepInterval)
+	l.Allow("active", rule)
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("refilled bucket was not swept")
+	}
+	if _, ok := l.buckets["active"]; !ok {
+		t.Error("active bucket was swept")
+	}
+}
@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"vector-database/auth"
+	"vector-database/config"
+	"vector-database/db/quota"
+	"vector-database/httpinfo"
+	"vector-database/logging"
+)
+
+// defaultRoute keys the buckets and quotas of routes without their own rule.
+const defaultRoute = "*"
+
+type policy struct {
+	cfg     config.RateLimit
+	routes  map[string]config.RateRule
+	proxies []netip.Prefix
+	limiter *Limiter
+	quotas  quota.Store
+}
+
+// Middleware limits each client per route. Clients are identified by the
+// authenticated API key, so it must run inside the auth middleware, or by
+// IP address. quotas may be nil when no rule sets a daily quota.
+func Middleware(cfg config.RateLimit, quotas quota.Store) (func(http.Handler) http.Handler, error) {
+	proxies, err := parseProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	p := &policy{
+		cfg:     cfg,
+		routes:  map[string]config.RateRule{},
+		proxies: proxies,
+		limiter: NewLimiter(),
+		quotas:  quotas,
+	}
+	for _, route := range cfg.Routes {
+		method, path, _ := strings.Cut(route.Route, " ")
+		if _, ok := httpinfo.Lookup(method, path); !ok {
+			return nil, fmt.Errorf("rateLimit route %q does not name an endpoint", route.Route)
+		}
+		p.routes[route.Route] = route.RateRule
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p.admit(w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}, nil
+}
+
+// IPMiddleware limits every request by client address with cfg.IP. It runs
+// in front of authentication, so clients without a valid key are throttled
+// before their key is looked up.
+func IPMiddleware(cfg config.RateLimit) (func(http.Handler) http.Handler, error) {
+	proxies, err := parseProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	limiter := NewLimiter()
+	rule := Rule{Rate: cfg.IP.Rate, Burst: cfg.IP.Burst}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ok, wait := limiter.Allow(clientIP(r, proxies), rule); !ok {
+				reject(w, r, wait, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// admit checks the rate limit and then the daily quota, and writes the 429
+// response when either is exhausted.
+func (p *policy) admit(w http.ResponseWriter, r *http.Request) bool {
+	route, rule := p.ruleFor(r)
+	client := p.client(r)
+
+	ok, wait := p.limiter.Allow(client+"|"+route, Rule{Rate: rule.Rate, Burst: rule.Burst})
+	if !ok {
+		reject(w, r, wait, "rate limit exceeded")
+		return false
+	}
+
+	if rule.Daily <= 0 || p.quotas == nil {
+		return true
+	}
+	now := time.Now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	reset := day.Add(24 * time.Hour)
+	count, err := p.quotas.Increment(r.Context(), client+"|"+route+"|"+day.Format(time.DateOnly), reset.Add(24*time.Hour))
+	if err != nil {
+		// Quotas fail open: an unavailable counter store must not take
+		// the API down with it.
+		logging.FromContext(r.Context()).Warn("check daily quota", "error", err)
+		return true
+	}
+	if count > rule.Daily {
+		reject(w, r, reset.Sub(now), fmt.Sprintf("daily quota of %d requests exceeded", rule.Daily))
+		return false
+	}
+	return true
+}
+
+func (p *policy) ruleFor(r *http.Request) (string, config.RateRule) {
+	if ep, ok := httpinfo.Lookup(r.Method, r.URL.Path); ok {
+		route := ep.Method + " " + ep.Path
+		if rule, ok := p.routes[route]; ok {
+			return route, rule
+		}
+	}
+	return defaultRoute, p.cfg.Default
+}
+
+func (p *policy) client(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		return "key:" + identity.KeyID
+	}
+	return "ip:" + clientIP(r, p.proxies)
+}
+
+func reject(w http.ResponseWriter, r *http.Request, wait time.Duration, msg string) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	httpinfo.WriteError(w, r, http.StatusTooManyRequests, httpinfo.APIError{
+		Message: msg,
+		Details: map[string]interface{}{"retry_after_seconds": seconds},
+	})
+}
@@ -0,0 +1,66 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	cases := []struct {
+		id string
+		ok bool
+	}{
+		{"3f2a9c0d6b1e4f7a8c5d2e9b0a1f6c3d", true},
+		{"0af76519-16cd-43dd-8448-eb211c80319c", true},
+		{"trace_id.span:1", true},
+		{strings.Repeat("a", maxLength), true},
+		{"", false},
+		{strings.Repeat("a", maxLength+1), false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{`quote"`, false},
+		{"ünïcode", false},
+	}
+	for _, tc := range cases {
+		if got := valid(tc.id); got != tc.ok {
+			t.Errorf("valid(%q) = %v, want %v", tc.id, got, tc.ok)
+		}
+	}
+}
+
+func TestMiddlewareRoundTripsTheHeader(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"client id", "abc-123", true},
+		{"no id", "", false},
+		{"invalid id", "not valid!", false},
+	}
+	for _, tc := range cases {
+		var seen string
+		handler := Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			seen = FromContext(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.header != "" {
+			req.Header.Set(Header, tc.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(Header)
+		if got != seen {
+			t.Errorf("%s: response header %q, context %q, want the same id", tc.name, got, seen)
+		}
+		if tc.keep && got != tc.header {
+			t.Errorf("%s: id = %q, want the client's %q", tc.name, got, tc.header)
+		}
+		if !tc.keep && (got == tc.header || !valid(got)) {
+			t.Errorf("%s: id = %q, want a generated id", tc.name, got)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize        = 32
+	defaultBatchConcurrency = 4
+	defaultBatchMaxRetries  = 3
+	retryBackoff            = 500 * time.Millisecond
+	// maxRetryBackoff caps every wait, including a provider's Retry-After
+	// hint, so one throttled batch cannot stall ingestion for long.
+	maxRetryBackoff = 10 * time.Second
+)
+
+// BatchOptions controls how bulk encodes are split and retried. Zero values
+// fall back to sensible defaults; a negative MaxRetries disables retries.
+type BatchOptions struct {
+	Size        int
+	Concurrency int
+	MaxRetries  int
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.Size <= 0 {
+		o.Size = defaultBatchSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultBatchConcurrency
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	} else if o.MaxRetries == 0 {
+		o.MaxRetries = defaultBatchMaxRetries
+	}
+	return o
+}
+
+// encodeAll splits texts into micro-batches, encodes up to
+// opts.Concurrency of them at once and retries batches that were throttled
+// or hit a temporary failure. Vectors are returned in input order.
+func encodeAll(ctx context.Context, encoder EncoderService, texts []string, opts BatchOptions) ([][]float32, error) {
+	opts = opts.withDefaults()
+	vectors := make([][]float32, len(texts))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		slots    = make(chan struct{}, opts.Concurrency)
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	for start := 0; start < len(texts); start += opts.Size {
+		end := min(start+opts.Size, len(texts))
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			batch, err := encodeWithRetry(ctx, encoder, texts[start:end], opts.MaxRetries)
+			if err != nil {
+				fail(fmt.Errorf("encode batch %d-%d: %w", start, end-1, err))
+				return
+			}
+			copy(vectors[start:end], batch)
+		}(start, end)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// encodeWithRetry retries a batch that failed with a RateLimitError or
+// ErrUnavailable. It waits for the provider's Retry-After hint, or else
+// backs off exponentially, and never longer than maxRetryBackoff.
+func encodeWithRetry(ctx context.Context, encoder EncoderService, texts []string, maxRetries int) ([][]float32, error) {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		vectors, err := encodeBatch(ctx, encoder, texts)
+		if err == nil || !retryable(err) || attempt >= maxRetries {
+			return vectors, err
+		}
+
+		wait := backoff
+		var limited *RateLimitError
+		if errors.As(err, &limited) && limited.RetryAfter > 0 {
+			wait = limited.RetryAfter
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+
+		timer := time.NewTimer(min(wait, maxRetryBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// retryable reports whether err is throttling or a temporary failure.
+// RateLimitError also matches ErrUnavailable.
+func retryable(err error) bool {
+	return errors.Is(err, ErrUnavailable)
+}
+
+func encodeBatch(ctx context.Context, encoder EncoderService, texts []string) ([][]float32, error) {
+	vectors, err := encoder.EncodeBatch(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("encoder returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// encodeEach implements EncodeBatch for local encoders that have no batch
+// endpoint to call.
+func encodeEach(ctx context.Context, encoder EncoderService, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector, err := encoder.Encode(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
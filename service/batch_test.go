@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lengthEncoder encodes each text as its length and records batch sizes.
+type lengthEncoder struct {
+	mu      sync.Mutex
+	batches []int
+}
+
+func (l *lengthEncoder) Encode(_ context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text))}, nil
+}
+
+func (l *lengthEncoder) EncodeBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	l.mu.Lock()
+	l.batches = append(l.batches, len(texts))
+	l.mu.Unlock()
+	return encodeEach(ctx, l, texts)
+}
+
+func TestEncodeAllKeepsInputOrder(t *testing.T) {
+	texts := make([]string, 10)
+	for i := range texts {
+		texts[i] = string(make([]byte, i)) + strconv.Itoa(i)
+	}
+	enc := &lengthEncoder{}
+
+	vectors, err := encodeAll(context.Background(), enc, texts, BatchOptions{Size: 3, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, vector := range vectors {
+		if int(vector[0]) != len(texts[i]) {
+			t.Errorf("vector %d = %v, want %d", i, vector, len(texts[i]))
+		}
+	}
+	if len(enc.batches) != 4 {
+		t.Errorf("batches = %v, want 4 batches of at most 3", enc.batches)
+	}
+}
+
+// throttledEncoder fails its first failures batch calls with err.
+type throttledEncoder struct {
+	lengthEncoder
+	failures int32
+	calls    atomic.Int32
+	err      error
+}
+
+func (e *throttledEncoder) EncodeBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if e.calls.Add(1) <= e.failures {
+		return nil, e.err
+	}
+	return e.lengthEncoder.EncodeBatch(ctx, texts)
+}
+
+func TestEncodeAllRetriesThrottledBatches(t *testing.T) {
+	enc := &throttledEncoder{failures: 2, err: &RateLimitError{RetryAfter: time.Millisecond, Err: errors.New("429")}}
+
+	vectors, err := encodeAll(context.Background(), enc, []string{"a", "bb"}, BatchOptions{Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 2 || vectors[1][0] != 2 {
+		t.Errorf("vectors = %v", vectors)
+	}
+	if calls := enc.calls.Load(); calls != 3 {
+		t.Errorf("encoder called %d times, want 3", calls)
+	}
+}
+
+func TestEncodeAllGivesUpAfterMaxRetries(t *testing.T) {
+	enc := &throttledEncoder{failures: 5, err: &RateLimitError{RetryAfter: time.Millisecond, Err: errors.New("429")}}
+
+	_, err := encodeAll(context.Background(), enc, []string{"a"}, BatchOptions{MaxRetries: 2})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want the rate limit error", err)
+	}
+	if calls := enc.calls.Load(); calls != 3 {
+		t.Errorf("encoder called %d times, want 3", calls)
+	}
+}
+
+func TestEncodeAllDoesNotRetryPermanentErrors(t *testing.T) {
+	enc := &throttledEncoder{failures: 1, err: errors.New("bad input")}
+
+	if _, err := encodeAll(context.Background(), enc, []string{"a"}, BatchOptions{}); err == nil {
+		t.Fatal("encodeAll succeeded")
+	}
+	if calls := enc.calls.Load(); calls != 1 {
+		t.Errorf("encoder called %d times, want 1", calls)
+	}
+}
+
+func TestEncodeAllStopsWaitingWhenCancelled(t *testing.T) {
+	enc := &throttledEncoder{failures: 1, err: &RateLimitError{RetryAfter: time.Hour, Err: errors.New("429")}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := encodeAll(ctx, enc, []string{"a"}, BatchOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context deadline", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("waited %v after the context ended", elapsed)
+	}
+}
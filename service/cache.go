@@ -0,0 +1,209 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+
+	"vector-database/db/embeddingcache"
+)
+
+// CacheStats reports how often the embedding cache avoided calling the encoder.
+type CacheStats struct {
+	MemoryHits     int64 `json:"memory_hits"`
+	PersistentHits int64 `json:"persistent_hits"`
+	Misses         int64 `json:"misses"`
+	Errors         int64 `json:"errors"`
+}
+
+// CachedEncoder decorates an EncoderService with an in-memory LRU tier and an
+// optional persistent tier.
+type CachedEncoder struct {
+	inner      EncoderService
+	identity   string
+	persistent embeddingcache.Store
+
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+
+	memoryHits     atomic.Int64
+	persistentHits atomic.Int64
+	misses         atomic.Int64
+	errors         atomic.Int64
+}
+
+type cacheEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewCachedEncoder caches the vectors produced by inner. The identity must
+// change whenever the encoder would produce different vectors for the same
+// text, since it is part of every cache key. A nil persistent store disables
+// the second tier. Encoders that implement CorpusObserver must not be cached:
+// their vectors change with every indexed document.
+func NewCachedEncoder(inner EncoderService, identity string, capacity int, persistent embeddingcache.Store) *CachedEncoder {
+	return &CachedEncoder{
+		inner:      inner,
+		identity:   identity,
+		persistent: persistent,
+		capacity:   capacity,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element, capacity),
+	}
+}
+
+// Encode serves the vector from the memory tier, then the persistent tier,
+// and only then from the wrapped encoder. Persistent tier failures are
+// counted and treated as misses so the cache never fails a request.
+func (c *CachedEncoder) Encode(ctx context.Context, text string) ([]float32, error) {
+	key := c.key(text)
+
+	if vector, ok := c.getMemory(key); ok {
+		c.memoryHits.Add(1)
+		return vector, nil
+	}
+
+	if c.persistent != nil {
+		vector, ok, err := c.persistent.Get(ctx, key)
+		if err != nil {
+			c.errors.Add(1)
+		} else if ok {
+			c.persistentHits.Add(1)
+			c.putMemory(key, vector)
+			return cloneVector(vector), nil
+		}
+	}
+
+	c.misses.Add(1)
+	vector, err := c.inner.Encode(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	c.putMemory(key, cloneVector(vector))
+	if c.persistent != nil {
+		if err := c.persistent.Put(ctx, key, vector); err != nil {
+			c.errors.Add(1)
+		}
+	}
+	return vector, nil
+}
+
+// EncodeBatch serves cached vectors and sends only the misses to the wrapped
+// encoder, in a single batch.
+func (c *CachedEncoder) EncodeBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	var (
+		missTexts   []string
+		missIndexes []int
+	)
+
+	for i, text := range texts {
+		keys[i] = c.key(text)
+		if vector, ok := c.getMemory(keys[i]); ok {
+			c.memoryHits.Add(1)
+			vectors[i] = vector
+			continue
+		}
+		if c.persistent != nil {
+			vector, ok, err := c.persistent.Get(ctx, keys[i])
+			if err != nil {
+				c.errors.Add(1)
+			} else if ok {
+				c.persistentHits.Add(1)
+				c.putMemory(keys[i], vector)
+				vectors[i] = cloneVector(vector)
+				continue
+			}
+		}
+		c.misses.Add(1)
+		missTexts = append(missTexts, text)
+		missIndexes = append(missIndexes, i)
+	}
+
+	if len(missTexts) == 0 {
+		return vectors, nil
+	}
+
+	encoded, err := c.inner.EncodeBatch(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+	for j, vector := range encoded {
+		i := missIndexes[j]
+		vectors[i] = vector
+		c.putMemory(keys[i], cloneVector(vector))
+		if c.persistent != nil {
+			if err := c.persistent.Put(ctx, keys[i], vector); err != nil {
+				c.errors.Add(1)
+			}
+		}
+	}
+	return vectors, nil
+}
+
+// Stats returns a snapshot of the hit/miss counters.
+func (c *CachedEncoder) Stats() CacheStats {
+	return CacheStats{
+		MemoryHits:     c.memoryHits.Load(),
+		PersistentHits: c.persistentHits.Load(),
+		Misses:         c.misses.Load(),
+		Errors:         c.errors.Load(),
+	}
+}
+
+func (c *CachedEncoder) key(text string) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(c.identity))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(text))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func (c *CachedEncoder) getMemory(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return cloneVector(elem.Value.(*cacheEntry).vector), true
+}
+
+func (c *CachedEncoder) putMemory(key string, vector []float32) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).vector = vector
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, vector: vector})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cloneVector copies cached vectors so callers cannot mutate shared state.
+func cloneVector(vector []float32) []float32 {
+	out := make([]float32, len(vector))
+	copy(out, vector)
+	return out
+}
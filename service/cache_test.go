@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+// countingEncoder returns a vector holding the number of texts encoded so
+// far, so tests can tell cached vectors from fresh ones.
+type countingEncoder struct {
+	calls int
+}
+
+func (c *countingEncoder) Encode(_ context.Context, _ string) ([]float32, error) {
+	c.calls++
+	return []float32{float32(c.calls)}, nil
+}
+
+func (c *countingEncoder) EncodeBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return encodeEach(ctx, c, texts)
+}
+
+func TestCachedEncoderServesRepeats(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingEncoder{}
+	cache := NewCachedEncoder(inner, "test", 2, nil)
+
+	first, _ := cache.Encode(ctx, "a")
+	again, _ := cache.Encode(ctx, "a")
+	if first[0] != again[0] || inner.calls != 1 {
+		t.Fatalf("repeat encode was not cached: %v then %v after %d calls", first, again, inner.calls)
+	}
+
+	vectors, err := cache.EncodeBatch(ctx, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vectors[0][0] != first[0] || inner.calls != 3 {
+		t.Errorf("batch = %v after %d calls, want only b and c encoded", vectors, inner.calls)
+	}
+
+	// The LRU holds two entries, so "a" was evicted by "b" and "c".
+	if _, _ = cache.Encode(ctx, "a"); inner.calls != 4 {
+		t.Errorf("evicted entry served from cache, %d calls", inner.calls)
+	}
+	if stats := cache.Stats(); stats.MemoryHits != 2 || stats.Misses != 4 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestInstrumentEncoderExposesObserverOnlyWhenInnerLearns(t *testing.T) {
+	if _, ok := InstrumentEncoder(&countingEncoder{}, "hash").(CorpusObserver); ok {
+		t.Error("instrumented hash encoder implements CorpusObserver")
+	}
+	if _, ok := InstrumentEncoder(newTestTFIDF(t), "tfidf").(CorpusObserver); !ok {
+		t.Error("instrumented tfidf encoder does not implement CorpusObserver")
+	}
+}
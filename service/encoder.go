@@ -5,7 +5,6 @@ import (
 	"errors"
 	"hash/fnv"
 	"math"
-	"strings"
 )
 
 func (h *encoderImp) Encode(_ context.Context, text string) ([]float32, error) {
@@ -18,7 +17,7 @@ func (h *encoderImp) Encode(_ context.Context, text string) ([]float32, error) {
 		return vector, nil
 	}
 
-	tokens := strings.Fields(strings.ToLower(text))
+	tokens := h.analyzer.Analyze(text)
 	for _, token := range tokens {
 		hasher := fnv.New64a()
 		_, _ = hasher.Write([]byte(token))
@@ -30,6 +29,10 @@ func (h *encoderImp) Encode(_ context.Context, text string) ([]float32, error) {
 	return vector, nil
 }
 
+func (h *encoderImp) EncodeBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return encodeEach(ctx, h, texts)
+}
+
 func normalise(vec []float32) {
 	var sum float64
 	for _, v := range vec {
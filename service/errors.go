@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"vector-database/db/document"
+)
+
+// Service methods wrap one of these with context, usually as
+// fmt.Errorf("%w: ...", ErrX), so callers can classify failures with
+// errors.Is.
+var (
+	// ErrInvalidArgument signals that the caller-provided payload is invalid.
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrNotFound signals that the referenced document does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict signals that the request clashes with the stored state.
+	ErrConflict = errors.New("conflict")
+	// ErrFailedPrecondition signals that the stored state does not allow
+	// the operation yet, such as training an index on an empty store.
+	ErrFailedPrecondition = errors.New("failed precondition")
+	// ErrDimensionMismatch signals a vector whose length is not the
+	// configured embedding dimension. See DimensionError.
+	ErrDimensionMismatch = errors.New("dimension mismatch")
+	// ErrUnavailable signals that the store or an upstream service such
+	// as the reranker could not be reached or gave up.
+	ErrUnavailable = errors.New("upstream unavailable")
+)
+
+// DimensionError reports a vector of the wrong length. It matches
+// ErrDimensionMismatch.
+type DimensionError struct {
+	Expected int
+	Got      int
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("vector dimension mismatch: expected %d, got %d", e.Expected, e.Got)
+}
+
+func (e *DimensionError) Is(target error) bool {
+	return target == ErrDimensionMismatch
+}
+
+// Details returns the expected and actual dimensions for error bodies.
+func (e *DimensionError) Details() map[string]interface{} {
+	return map[string]interface{}{"expected": e.Expected, "got": e.Got}
+}
+
+// storeError sorts a store failure into the error categories: duplicate
+// keys become ErrConflict, an empty store ErrFailedPrecondition, and
+// connection failures and timeouts ErrUnavailable. Other errors are
+// returned unchanged.
+func storeError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	case errors.Is(err, document.ErrEmptyStore):
+		return fmt.Errorf("%w: %w", ErrFailedPrecondition, err)
+	case mongo.IsNetworkError(err), mongo.IsTimeout(err):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	default:
+		return err
+	}
+}
+
+// RateLimitError is returned when a provider, such as the reranker or a
+// remote encoder, throttled the request. RetryAfter is zero when the
+// provider gave no hint.
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited: %v", e.Err)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports the throttled provider as unavailable.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// Details returns the provider's retry hint for error bodies.
+func (e *RateLimitError) Details() map[string]interface{} {
+	if e.RetryAfter <= 0 {
+		return nil
+	}
+	return map[string]interface{}{"retry_after_seconds": int(math.Ceil(e.RetryAfter.Seconds()))}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/db/document"
+	"vector-database/model"
+)
+
+// staleStore holds a document embedded with another dimension.
+type staleStore struct {
+	document.Store
+}
+
+func (staleStore) GetDocuments(_ context.Context, ids []primitive.ObjectID) ([]model.Document, error) {
+	docs := make([]model.Document, len(ids))
+	for i, id := range ids {
+		docs[i] = model.Document{ID: id, Content: "old", Embedding: []float32{1, 0}}
+	}
+	return docs, nil
+}
+
+func TestStoredDimensionMismatchIsInternal(t *testing.T) {
+	svc, err := NewSearch(staleStore{}, &lengthEncoder{}, 3, BatchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.(*searchImp).loadDocuments(context.Background(), []string{primitive.NewObjectID().Hex()})
+	if err == nil {
+		t.Fatal("loaded a document of the wrong dimension")
+	}
+	for _, sentinel := range []error{ErrDimensionMismatch, ErrInvalidArgument, ErrNotFound} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("%v matches %v, want an internal error", err, sentinel)
+		}
+	}
+}
+
+func TestStoreErrorCategories(t *testing.T) {
+	if err := storeError(document.ErrEmptyStore); !errors.Is(err, ErrFailedPrecondition) || errors.Is(err, ErrConflict) {
+		t.Errorf("empty store: %v, want ErrFailedPrecondition", err)
+	}
+	plain := errors.New("boom")
+	if err := storeError(plain); err != plain {
+		t.Errorf("storeError(%v) = %v, want it unchanged", plain, err)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"vector-database/db/document"
+	"vector-database/model"
+	"vector-database/tracing"
+)
+
+// GroupByText searches by text and buckets the candidates on a metadata
+// field. Stores that implement document.Grouper group in the database;
+// otherwise, and for exact searches that need rescoring first, the
+// candidate set is grouped here.
+func (s *searchImp) GroupByText(ctx context.Context, query model.GroupQuery) (model.GroupedResults, error) {
+	ctx, span := tracing.Start(ctx, "service.GroupByText")
+	defer span.End()
+
+	opts := query.Options
+	if opts.Limit <= 0 {
+		return model.GroupedResults{}, fmt.Errorf("%w: limit must be positive", ErrInvalidArgument)
+	}
+	if err := opts.Validate(); err != nil {
+		return model.GroupedResults{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if err := query.Validate(); err != nil {
+		return model.GroupedResults{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	vector, err := s.encoder.Encode(ctx, query.Text)
+	if err != nil {
+		return model.GroupedResults{}, fmt.Errorf("encode query: %w", err)
+	}
+
+	vq := model.VectorQuery{
+		QueryVector: vector,
+		Limit:       query.Window(),
+		Exact:       opts.Exact,
+		MinScore:    opts.MinScore,
+	}
+	if err := vq.Validate(s.dim); err != nil {
+		return model.GroupedResults{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	var results model.GroupedResults
+	if grouper, ok := s.store.(document.Grouper); ok && !opts.Exact {
+		results, err = grouper.GroupSearch(ctx, vq, query.Field, opts.Limit, query.PerGroup)
+		if err != nil {
+			return model.GroupedResults{}, storeError(err)
+		}
+	} else {
+		docs, err := s.store.SimilaritySearch(ctx, vq)
+		if err != nil {
+			return model.GroupedResults{}, storeError(err)
+		}
+		results = model.GroupDocuments(docs, query.Field, opts.Limit, query.PerGroup)
+	}
+
+	searchResults.Observe(float64(len(results.Groups)), "grouped")
+	return results, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"vector-database/db/document"
+	"vector-database/logging"
+	"vector-database/model"
+)
+
+// readinessProbe is encoded by the encoder self-test.
+const readinessProbe = "readiness probe"
+
+// Pinger checks a backing service is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthService answers orchestrator probes and operator status requests.
+type HealthService interface {
+	Ready(ctx context.Context) Readiness
+	Status(ctx context.Context) (Status, error)
+}
+
+// Check is the outcome of one readiness check. Error only says that the
+// check failed; the cause is logged, since probes are unauthenticated.
+type Check struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Readiness is ready only when every check passed.
+type Readiness struct {
+	Ready  bool    `json:"ready"`
+	Checks []Check `json:"checks"`
+}
+
+// Status describes the running service.
+type Status struct {
+	Version   string            `json:"version"`
+	StartedAt time.Time         `json:"started_at"`
+	Uptime    string            `json:"uptime"`
+	Store     model.StoreStatus `json:"store"`
+	Cache     *CacheStats       `json:"cache,omitempty"`
+}
+
+type healthImp struct {
+	mongo   Pinger
+	store   document.Store
+	encoder EncoderService
+	dim     int
+	version string
+	started time.Time
+}
+
+// NewHealth wires the dependencies readiness depends on. A nil mongo skips
+// the Mongo check, for deployments that run without Mongo. encoder should
+// be the bare encoder: behind a cache, every self-test after the first
+// would be answered from the cache.
+func NewHealth(mongo Pinger, store document.Store, encoder EncoderService, dim int, version string) HealthService {
+	return &healthImp{
+		mongo:   mongo,
+		store:   store,
+		encoder: encoder,
+		dim:     dim,
+		version: version,
+		started: time.Now().UTC(),
+	}
+}
+
+// Ready pings Mongo, checks the vector index is queryable and encodes a
+// probe text.
+func (h *healthImp) Ready(ctx context.Context) Readiness {
+	var checks []Check
+	if h.mongo != nil {
+		checks = append(checks, runCheck(ctx, "mongo", func() error { return h.mongo.Ping(ctx) }))
+	}
+	checks = append(checks,
+		runCheck(ctx, "index", func() error {
+			index, err := h.store.IndexStatus(ctx)
+			if err != nil {
+				return err
+			}
+			if !index.Queryable {
+				return fmt.Errorf("index %s is %s", index.Name, index.Status)
+			}
+			return nil
+		}),
+		runCheck(ctx, "encoder", func() error { return h.selfTest(ctx) }),
+	)
+
+	readiness := Readiness{Ready: true, Checks: checks}
+	for _, check := range checks {
+		readiness.Ready = readiness.Ready && check.OK
+	}
+	return readiness
+}
+
+func (h *healthImp) selfTest(ctx context.Context) error {
+	vector, err := h.encoder.Encode(ctx, readinessProbe)
+	if err != nil {
+		return err
+	}
+	if len(vector) != h.dim {
+		return fmt.Errorf("encoder returned %d dimensions, expected %d", len(vector), h.dim)
+	}
+	for _, v := range vector {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return fmt.Errorf("encoder returned a non-finite value")
+		}
+	}
+	return nil
+}
+
+func (h *healthImp) Status(ctx context.Context) (Status, error) {
+	store, err := h.store.Status(ctx)
+	if err != nil {
+		return Status{}, storeError(err)
+	}
+
+	status := Status{
+		Version:   h.version,
+		StartedAt: h.started,
+		Uptime:    time.Since(h.started).Round(time.Second).String(),
+		Store:     store,
+	}
+	if cached, ok := h.encoder.(interface{ Stats() CacheStats }); ok {
+		stats := cached.Stats()
+		status.Cache = &stats
+	}
+	return status, nil
+}
+
+func runCheck(ctx context.Context, name string, check func() error) Check {
+	if err := check(); err != nil {
+		logging.FromContext(ctx).Warn("readiness check failed", "check", name, "error", err)
+		return Check{Name: name, Error: "unavailable"}
+	}
+	return Check{Name: name, OK: true}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"vector-database/config"
+	"vector-database/db/document"
+)
+
+type fakePinger struct{ err error }
+
+func (p fakePinger) Ping(context.Context) error { return p.err }
+
+func TestReadyHidesCheckErrors(t *testing.T) {
+	store, err := document.NewMemoryStore(config.MongoDB{EmbeddingDimension: 1}, config.Store{Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ping := fakePinger{err: errors.New("dial tcp 10.0.0.7:27017: connection refused")}
+	health := NewHealth(ping, store, &lengthEncoder{}, 1, "test")
+
+	readiness := health.Ready(context.Background())
+	if readiness.Ready {
+		t.Fatal("ready with a failing Mongo ping")
+	}
+	for _, check := range readiness.Checks {
+		if check.OK == (check.Name == "mongo") || strings.Contains(check.Error, "10.0.0.7") {
+			t.Errorf("check %+v, want only a generic mongo failure", check)
+		}
+	}
+}
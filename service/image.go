@@ -20,9 +20,6 @@ const (
 	imageChunkSize              = 64
 )
 
-// ErrInvalidArgument signals that the caller-provided payload is invalid.
-var ErrInvalidArgument = errors.New("invalid argument")
-
 func (s *searchImp) InsertImage(ctx context.Context, input model.ImageInput) (model.ImageDocument, error) {
 	if err := input.Validate(); err != nil {
 		return model.ImageDocument{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
@@ -58,13 +55,13 @@ func (s *searchImp) SearchImages(ctx context.Context, query model.ImageQuery) ([
 		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
 	}
 
-	limit := query.Limit
-	if limit == 0 {
-		limit = 5
+	opts := query.Options
+	if opts.Limit == 0 {
+		opts.Limit = 5
 	}
 
 	payload := composeImageEmbeddingText(query.Description, imageBytes)
-	docs, err := s.SearchByText(ctx, payload, limit)
+	docs, err := s.SearchByText(ctx, payload, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"vector-database/metrics"
+	"vector-database/tracing"
+)
+
+var (
+	encodeDuration = metrics.NewHistogramVec(
+		"encoder_duration_seconds",
+		"Time spent encoding text into vectors, by encoder and call.",
+		metrics.LatencyBuckets,
+		"encoder", "operation",
+	)
+	encodeErrors = metrics.NewCounterVec(
+		"encoder_errors_total",
+		"Encoder calls that failed, by encoder and call.",
+		"encoder", "operation",
+	)
+	searchResults = metrics.NewHistogramVec(
+		"search_results",
+		"Number of results returned per search, by kind of search.",
+		metrics.CountBuckets,
+		"operation",
+	)
+	documentsIndexed = metrics.NewCounterVec(
+		"documents_indexed_total",
+		"Documents encoded and written to the store, by operation.",
+		"operation",
+	)
+)
+
+// instrumentedEncoder traces calls to the wrapped encoder and records their
+// latency and failures.
+type instrumentedEncoder struct {
+	inner EncoderService
+	name  string
+}
+
+// observingEncoder is an instrumentedEncoder whose inner encoder learns
+// corpus statistics. It is a separate type so the decorator implements
+// CorpusObserver only when the wrapped encoder does.
+type observingEncoder struct {
+	*instrumentedEncoder
+	observer CorpusObserver
+}
+
+// InstrumentEncoder traces and times every call to inner under the given
+// encoder name. Wrap the base encoder, below any cache, so only real encodes
+// are measured.
+func InstrumentEncoder(inner EncoderService, name string) EncoderService {
+	enc := &instrumentedEncoder{inner: inner, name: name}
+	if observer, ok := inner.(CorpusObserver); ok {
+		return &observingEncoder{instrumentedEncoder: enc, observer: observer}
+	}
+	return enc
+}
+
+func (e *instrumentedEncoder) Encode(ctx context.Context, text string) ([]float32, error) {
+	ctx, span := e.startSpan(ctx, "Encode")
+	span.SetAttributes(attribute.Int("encoder.text_length", len(text)))
+
+	start := time.Now()
+	vector, err := e.inner.Encode(ctx, text)
+	e.record(start, span, "encode", err)
+	return vector, err
+}
+
+func (e *instrumentedEncoder) EncodeBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	ctx, span := e.startSpan(ctx, "EncodeBatch")
+	span.SetAttributes(attribute.Int("encoder.batch_size", len(texts)))
+
+	start := time.Now()
+	vectors, err := e.inner.EncodeBatch(ctx, texts)
+	e.record(start, span, "encode_batch", err)
+	return vectors, err
+}
+
+func (e *observingEncoder) Observe(ctx context.Context, text string) error {
+	return e.observer.Observe(ctx, text)
+}
+
+func (e *instrumentedEncoder) startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "encoder."+method, trace.WithAttributes(attribute.String("encoder.name", e.name)))
+}
+
+func (e *instrumentedEncoder) record(start time.Time, span trace.Span, operation string, err error) {
+	encodeDuration.ObserveSince(start, e.name, operation)
+	if err != nil {
+		encodeErrors.Inc(e.name, operation)
+	}
+	tracing.RecordError(span, err)
+	span.End()
+}
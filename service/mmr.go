@@ -0,0 +1,74 @@
+package service
+
+import (
+	"math"
+
+	"vector-database/db/document"
+	"vector-database/model"
+)
+
+// mmrOversample is how many candidates per returned result MMR chooses from.
+const mmrOversample = 4
+
+// selectMMR greedily picks k documents, each maximising
+// lambda*relevance - (1-lambda)*max similarity to the documents already
+// picked. Relevance is the search score; similarity is the cosine of the
+// stored embeddings mapped to [0, 1] like search scores, so lambda weighs
+// terms on the same scale. Reranked scores are unbounded, so when any
+// falls outside [0, 1] relevance is min-max scaled into it.
+func selectMMR(candidates []model.Document, k int, lambda float64) []model.Document {
+	k = min(k, len(candidates))
+	selected := make([]model.Document, 0, k)
+	used := make([]bool, len(candidates))
+	relevance := mmrRelevance(candidates)
+	// maxSim[i] tracks candidate i's highest similarity to any selected document.
+	maxSim := make([]float64, len(candidates))
+
+	for len(selected) < k {
+		best, bestValue := -1, math.Inf(-1)
+		for i := range candidates {
+			if used[i] {
+				continue
+			}
+			value := lambda*relevance[i] - (1-lambda)*maxSim[i]
+			if value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+
+		used[best] = true
+		picked := candidates[best]
+		selected = append(selected, picked)
+		for i, doc := range candidates {
+			if used[i] {
+				continue
+			}
+			if sim := document.CosineScore(doc.Embedding, picked.Embedding); sim > maxSim[i] {
+				maxSim[i] = sim
+			}
+		}
+	}
+	return selected
+}
+
+// mmrRelevance returns the candidates' scores, min-max scaled when they do
+// not already lie in [0, 1].
+func mmrRelevance(candidates []model.Document) []float64 {
+	relevance := make([]float64, len(candidates))
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for i, doc := range candidates {
+		relevance[i] = doc.Score
+		lowest, highest = math.Min(lowest, doc.Score), math.Max(highest, doc.Score)
+	}
+	if lowest >= 0 && highest <= 1 {
+		return relevance
+	}
+	for i := range relevance {
+		if highest > lowest {
+			relevance[i] = (relevance[i] - lowest) / (highest - lowest)
+		} else {
+			relevance[i] = 1
+		}
+	}
+	return relevance
+}
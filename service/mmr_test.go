@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/model"
+)
+
+func mmrCandidate(content string, score float64, embedding ...float32) model.Document {
+	return model.Document{ID: primitive.NewObjectID(), Content: content, Score: score, Embedding: embedding}
+}
+
+func contents(docs []model.Document) []string {
+	out := make([]string, len(docs))
+	for i, doc := range docs {
+		out[i] = doc.Content
+	}
+	return out
+}
+
+func TestSelectMMRSkipsNearDuplicates(t *testing.T) {
+	candidates := []model.Document{
+		mmrCandidate("a", 0.95, 1, 0),
+		mmrCandidate("a-copy", 0.94, 1, 0.01),
+		mmrCandidate("b", 0.80, 0, 1),
+	}
+
+	got := contents(selectMMR(candidates, 2, 0.5))
+	if got[0] != "a" || got[1] != "b" {
+		t.Errorf("lambda 0.5 picked %v, want [a b]", got)
+	}
+	got = contents(selectMMR(candidates, 2, 1))
+	if got[0] != "a" || got[1] != "a-copy" {
+		t.Errorf("lambda 1 picked %v, want [a a-copy]", got)
+	}
+}
+
+func TestSelectMMRTreatsOppositeVectorsAsMostDiverse(t *testing.T) {
+	candidates := []model.Document{
+		mmrCandidate("a", 0.9, 1, 0),
+		mmrCandidate("orthogonal", 0.7, 0, 1),
+		mmrCandidate("opposite", 0.7, -1, 0),
+	}
+
+	got := contents(selectMMR(candidates, 2, 0.5))
+	if got[1] != "opposite" {
+		t.Errorf("picked %v, want the opposite vector second", got)
+	}
+}
+
+func TestMMRRelevanceScalesUnboundedScores(t *testing.T) {
+	bounded := []model.Document{mmrCandidate("a", 0.2), mmrCandidate("b", 0.9)}
+	if got := mmrRelevance(bounded); got[0] != 0.2 || got[1] != 0.9 {
+		t.Errorf("scores in [0, 1] changed to %v", got)
+	}
+
+	reranked := []model.Document{mmrCandidate("a", -3), mmrCandidate("b", 5), mmrCandidate("c", 1)}
+	got := mmrRelevance(reranked)
+	want := []float64{0, 1, 0.5}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("scaled %v, want %v", got, want)
+		}
+	}
+}
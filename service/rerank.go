@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/analysis"
+	"vector-database/db/document"
+	"vector-database/model"
+)
+
+// rerankOversample is how many candidates per returned result a reranker sees.
+const rerankOversample = 4
+
+// Reranker rescores retrieved documents in a second stage. Implementations
+// return the documents ordered by their new Score.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, docs []model.Document) ([]model.Document, error)
+}
+
+type rerankChain []Reranker
+
+// NewRerankChain runs the rerankers in order, each seeing the previous
+// stage's scores.
+func NewRerankChain(stages ...Reranker) Reranker {
+	return rerankChain(stages)
+}
+
+func (c rerankChain) Rerank(ctx context.Context, query string, docs []model.Document) ([]model.Document, error) {
+	var err error
+	for _, stage := range c {
+		if docs, err = stage.Rerank(ctx, query, docs); err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+type lexicalReranker struct {
+	analyzer analysis.Analyzer
+	weight   float64
+}
+
+// NewLexicalReranker blends the retrieval score with the fraction of
+// distinct query terms that appear in the document:
+// (1-weight)*score + weight*overlap.
+func NewLexicalReranker(analyzer analysis.Analyzer, weight float64) Reranker {
+	return &lexicalReranker{analyzer: analyzer, weight: weight}
+}
+
+func (l *lexicalReranker) Rerank(_ context.Context, query string, docs []model.Document) ([]model.Document, error) {
+	queryTerms := termSet(l.analyzer.Analyze(query))
+	if len(queryTerms) == 0 {
+		return docs, nil
+	}
+
+	for i := range docs {
+		docTerms := termSet(l.analyzer.Analyze(docs[i].Content))
+		var shared int
+		for term := range queryTerms {
+			if _, ok := docTerms[term]; ok {
+				shared++
+			}
+		}
+		overlap := float64(shared) / float64(len(queryTerms))
+		docs[i].Score = (1-l.weight)*docs[i].Score + l.weight*overlap
+	}
+	document.SortByScore(docs)
+	return docs, nil
+}
+
+// MetadataBoostRule adjusts scores from a metadata field. With HalfLife set
+// the field is read as a timestamp and the score decays with its age:
+// score * (1 - Weight + Weight * 0.5^(age/HalfLife)). Otherwise the score is
+// multiplied by Boost when the field equals Equals.
+type MetadataBoostRule struct {
+	Field    string
+	Equals   interface{}
+	Boost    float64
+	HalfLife time.Duration
+	Weight   float64
+}
+
+type metadataReranker struct {
+	rules []MetadataBoostRule
+	now   func() time.Time
+}
+
+// NewMetadataReranker applies the boost rules in order.
+func NewMetadataReranker(rules []MetadataBoostRule) Reranker {
+	return &metadataReranker{rules: rules, now: time.Now}
+}
+
+func (m *metadataReranker) Rerank(_ context.Context, _ string, docs []model.Document) ([]model.Document, error) {
+	now := m.now()
+	for i := range docs {
+		for _, rule := range m.rules {
+			value, ok := metadataValue(docs[i].Metadata, rule.Field)
+			if !ok {
+				continue
+			}
+			if rule.HalfLife > 0 {
+				ts, ok := asTime(value)
+				if !ok {
+					continue
+				}
+				age := max(now.Sub(ts), 0)
+				decay := math.Pow(0.5, float64(age)/float64(rule.HalfLife))
+				docs[i].Score *= 1 - rule.Weight + rule.Weight*decay
+				continue
+			}
+			if metadataEquals(value, rule.Equals) {
+				docs[i].Score *= rule.Boost
+			}
+		}
+	}
+	document.SortByScore(docs)
+	return docs, nil
+}
+
+// metadataValue resolves a dotted path inside the document metadata.
+func metadataValue(metadata map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = metadata
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// asTime accepts RFC 3339 strings, Unix seconds and BSON dates.
+func asTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case primitive.DateTime:
+		return v.Time(), true
+	case string:
+		ts, err := time.Parse(time.RFC3339, v)
+		return ts, err == nil
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int32:
+		return time.Unix(int64(v), 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
+
+func metadataEquals(a, b interface{}) bool {
+	if fa, ok := asFloat(a); ok {
+		if fb, ok := asFloat(b); ok {
+			return fa == fb
+		}
+	}
+	return a == b
+}
+
+func asFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func termSet(terms []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(terms))
+	for _, term := range terms {
+		set[term] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"vector-database/db/document"
+	"vector-database/model"
+	"vector-database/tracing"
+)
+
+// defaultRerankTimeout bounds reranker calls when no timeout is configured.
+const defaultRerankTimeout = 10 * time.Second
+
+// HTTPRerankOptions configures a cross-encoder behind a Cohere/Jina-style
+// rerank endpoint. A zero Timeout uses defaultRerankTimeout.
+type HTTPRerankOptions struct {
+	URL     string
+	APIKey  string
+	Model   string
+	Timeout time.Duration
+}
+
+type httpReranker struct {
+	opts   HTTPRerankOptions
+	client *http.Client
+}
+
+// NewHTTPReranker calls opts.URL with {model, query, documents, top_n} and
+// expects {results: [{index, relevance_score}]} back. A nil client uses one
+// with opts.Timeout.
+func NewHTTPReranker(opts HTTPRerankOptions, client *http.Client) Reranker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultRerankTimeout
+	}
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+	return &httpReranker{opts: opts, client: client}
+}
+
+type rerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func (h *httpReranker) Rerank(ctx context.Context, query string, docs []model.Document) ([]model.Document, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Content
+	}
+	body, err := json.Marshal(rerankRequest{
+		Model:     h.opts.Model,
+		Query:     query,
+		Documents: texts,
+		TopN:      len(texts),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode rerank request: %w", err)
+	}
+
+	ctx, span := tracing.Start(ctx, "reranker.Rerank",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("rerank.documents", len(texts))),
+	)
+	defer span.End()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build rerank request: %w", err)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	req.Header.Set("Content-Type", "application/json")
+	if h.opts.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.opts.APIKey)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, fmt.Errorf("%w: call reranker: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return nil, &RateLimitError{
+			RetryAfter: time.Duration(seconds) * time.Second,
+			Err:        fmt.Errorf("reranker returned %s", resp.Status),
+		}
+	}
+	if resp.StatusCode/100 != 2 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("reranker returned %s: %s", resp.Status, bytes.TrimSpace(snippet))
+		if resp.StatusCode >= 500 {
+			err = fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return nil, err
+	}
+
+	// A malformed response is the reranker's fault, so it is reported as
+	// an upstream failure like an unreachable reranker.
+	var decoded rerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("%w: decode rerank response: %w", ErrUnavailable, err)
+	}
+
+	reranked := make([]model.Document, 0, len(decoded.Results))
+	seen := make(map[int]bool, len(decoded.Results))
+	for _, result := range decoded.Results {
+		if result.Index < 0 || result.Index >= len(docs) {
+			return nil, fmt.Errorf("%w: reranker returned out of range index %d", ErrUnavailable, result.Index)
+		}
+		if seen[result.Index] {
+			return nil, fmt.Errorf("%w: reranker returned index %d twice", ErrUnavailable, result.Index)
+		}
+		seen[result.Index] = true
+		doc := docs[result.Index]
+		doc.Score = result.RelevanceScore
+		reranked = append(reranked, doc)
+	}
+	document.SortByScore(reranked)
+	return reranked, nil
+}
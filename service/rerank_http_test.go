@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/model"
+)
+
+func rerankDocs(contents ...string) []model.Document {
+	docs := make([]model.Document, len(contents))
+	for i, content := range contents {
+		docs[i] = model.Document{Content: content, Score: 0.5}
+	}
+	return docs
+}
+
+func TestHTTPRerankerReordersByRelevance(t *testing.T) {
+	var got rerankRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results": [{"index": 0, "relevance_score": 0.1}, {"index": 2, "relevance_score": 0.9}, {"index": 1, "relevance_score": 0.4}]}`))
+	}))
+	defer server.Close()
+
+	reranker := NewHTTPReranker(HTTPRerankOptions{URL: server.URL, APIKey: "secret", Model: "rerank-small"}, nil)
+	docs, err := reranker.Rerank(context.Background(), "query", rerankDocs("a", "b", "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := rerankRequest{Model: "rerank-small", Query: "query", Documents: []string{"a", "b", "c"}, TopN: 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("request = %+v, want %+v", got, want)
+	}
+	if order := contents(docs); !reflect.DeepEqual(order, []string{"c", "b", "a"}) {
+		t.Errorf("order = %v, want [c b a]", order)
+	}
+	if docs[0].Score != 0.9 {
+		t.Errorf("top score = %v, want 0.9", docs[0].Score)
+	}
+}
+
+func TestHTTPRerankerBreaksTiesByID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"results": [{"index": 0, "relevance_score": 0.5}, {"index": 1, "relevance_score": 0.5}, {"index": 2, "relevance_score": 0.5}]}`))
+	}))
+	defer server.Close()
+
+	docs := rerankDocs("a", "b", "c")
+	for i, id := range []string{"000000000000000000000003", "000000000000000000000001", "000000000000000000000002"} {
+		docs[i].ID, _ = primitive.ObjectIDFromHex(id)
+	}
+	reranked, err := NewHTTPReranker(HTTPRerankOptions{URL: server.URL}, nil).Rerank(context.Background(), "q", docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order := contents(reranked); !reflect.DeepEqual(order, []string{"b", "c", "a"}) {
+		t.Errorf("order = %v, want ties broken by id as [b c a]", order)
+	}
+}
+
+func TestHTTPRerankerErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		serve func(w http.ResponseWriter)
+		check func(t *testing.T, err error)
+	}{
+		{
+			name: "rate limited",
+			serve: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			check: func(t *testing.T, err error) {
+				var limited *RateLimitError
+				if !errors.As(err, &limited) || limited.RetryAfter != 7*time.Second {
+					t.Errorf("got %v, want a RateLimitError retrying after 7s", err)
+				}
+				if !errors.Is(err, ErrUnavailable) {
+					t.Errorf("rate limit %v does not match ErrUnavailable", err)
+				}
+			},
+		},
+		{
+			name:  "server error",
+			serve: func(w http.ResponseWriter) { http.Error(w, "boom", http.StatusBadGateway) },
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrUnavailable) {
+					t.Errorf("got %v, want ErrUnavailable", err)
+				}
+			},
+		},
+		{
+			name:  "client error",
+			serve: func(w http.ResponseWriter) { http.Error(w, "bad model", http.StatusBadRequest) },
+			check: func(t *testing.T, err error) {
+				if err == nil || errors.Is(err, ErrUnavailable) {
+					t.Errorf("got %v, want a non-retryable error", err)
+				}
+			},
+		},
+		{
+			name: "index out of range",
+			serve: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte(`{"results": [{"index": 5, "relevance_score": 1}]}`))
+			},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrUnavailable) {
+					t.Errorf("got %v for an out of range index, want ErrUnavailable", err)
+				}
+			},
+		},
+		{
+			name: "duplicate index",
+			serve: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte(`{"results": [{"index": 0, "relevance_score": 1}, {"index": 0, "relevance_score": 0.5}]}`))
+			},
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrUnavailable) {
+					t.Errorf("got %v for a duplicate index, want ErrUnavailable", err)
+				}
+			},
+		},
+		{
+			name:  "malformed body",
+			serve: func(w http.ResponseWriter) { _, _ = w.Write([]byte(`{"results": [`)) },
+			check: func(t *testing.T, err error) {
+				if !errors.Is(err, ErrUnavailable) {
+					t.Errorf("got %v for a malformed body, want ErrUnavailable", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { tc.serve(w) }))
+			defer server.Close()
+
+			_, err := NewHTTPReranker(HTTPRerankOptions{URL: server.URL}, nil).Rerank(context.Background(), "q", rerankDocs("a"))
+			tc.check(t, err)
+		})
+	}
+}
+
+func TestHTTPRerankerDefaultsTimeout(t *testing.T) {
+	reranker := NewHTTPReranker(HTTPRerankOptions{URL: "http://example.invalid"}, nil).(*httpReranker)
+	if reranker.client.Timeout != defaultRerankTimeout {
+		t.Errorf("client timeout = %v, want %v", reranker.client.Timeout, defaultRerankTimeout)
+	}
+}
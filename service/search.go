@@ -3,12 +3,20 @@ package service
 import (
 	"context"
 	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/logging"
 	"vector-database/model"
+	"vector-database/tracing"
 )
 
 func (s *searchImp) IndexDocument(ctx context.Context, input model.DocumentInput) (model.Document, error) {
+	ctx, span := tracing.Start(ctx, "service.IndexDocument")
+	defer span.End()
+
 	if err := input.Validate(); err != nil {
-		return model.Document{}, err
+		return model.Document{}, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
 	}
 
 	vector, err := s.encoder.Encode(ctx, input.Content)
@@ -16,12 +24,80 @@ func (s *searchImp) IndexDocument(ctx context.Context, input model.DocumentInput
 		return model.Document{}, fmt.Errorf("encode content: %w", err)
 	}
 
-	return s.store.InsertDocument(ctx, input, vector)
+	doc, err := s.store.InsertDocument(ctx, input, vector)
+	if err != nil {
+		return model.Document{}, storeError(err)
+	}
+	s.observe(ctx, []string{input.Content})
+	documentsIndexed.Inc("insert")
+	return doc, nil
+}
+
+// IndexDocuments encodes the inputs in micro-batches and inserts them in a
+// single write. Validation and encoding failures return before anything is
+// written, and corpus statistics are only updated once the write succeeds.
+func (s *searchImp) IndexDocuments(ctx context.Context, inputs []model.DocumentInput) ([]model.Document, error) {
+	ctx, span := tracing.Start(ctx, "service.IndexDocuments")
+	defer span.End()
+
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: at least one document is required", ErrInvalidArgument)
+	}
+
+	texts := make([]string, len(inputs))
+	for i, input := range inputs {
+		if err := input.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: document %d: %v", ErrInvalidArgument, i, err)
+		}
+		texts[i] = input.Content
+	}
+
+	vectors, err := encodeAll(ctx, s.encoder, texts, s.batch)
+	if err != nil {
+		return nil, fmt.Errorf("encode content: %w", err)
+	}
+
+	docs, err := s.store.InsertDocuments(ctx, inputs, vectors)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	s.observe(ctx, texts)
+	documentsIndexed.Add(float64(len(docs)), "batch")
+	return docs, nil
+}
+
+// observe updates the encoder's corpus statistics once a write has been
+// stored, so failed writes never skew them. The document is already stored
+// when this runs, so failures are logged rather than returned.
+func (s *searchImp) observe(ctx context.Context, texts []string) {
+	observer, ok := s.encoder.(CorpusObserver)
+	if !ok {
+		return
+	}
+	for _, text := range texts {
+		if err := observer.Observe(ctx, text); err != nil {
+			logging.FromContext(ctx).Warn("update corpus statistics", "error", err)
+		}
+	}
+}
+
+func parseDocumentID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("%w: invalid document id %q", ErrInvalidArgument, id)
+	}
+	return objectID, nil
 }
 
-func (s *searchImp) SearchByText(ctx context.Context, text string, limit int) ([]model.Document, error) {
-	if limit <= 0 {
-		return nil, fmt.Errorf("limit must be positive")
+func (s *searchImp) SearchByText(ctx context.Context, text string, opts model.SearchOptions) ([]model.Document, error) {
+	ctx, span := tracing.Start(ctx, "service.SearchByText")
+	defer span.End()
+
+	if opts.Limit <= 0 {
+		return nil, fmt.Errorf("%w: limit must be positive", ErrInvalidArgument)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
 	}
 
 	vector, err := s.encoder.Encode(ctx, text)
@@ -29,17 +105,86 @@ func (s *searchImp) SearchByText(ctx context.Context, text string, limit int) ([
 		return nil, fmt.Errorf("encode query: %w", err)
 	}
 
+	docs, err := s.searchVector(ctx, text, vector, opts)
+	if err != nil {
+		return nil, err
+	}
+	searchResults.Observe(float64(len(docs)), "text")
+	return docs, nil
+}
+
+// searchVector runs a validated search for an encoded query. text is what
+// rerankers score against.
+func (s *searchImp) searchVector(ctx context.Context, text string, vector []float32, opts model.SearchOptions) ([]model.Document, error) {
+	if opts.MMR || opts.Rerank {
+		return s.searchTwoStage(ctx, text, vector, opts)
+	}
+
+	query := opts.Apply(model.VectorQuery{QueryVector: vector})
+	if err := query.Validate(s.dim); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	docs, err := s.store.SimilaritySearch(ctx, query)
+	return docs, storeError(err)
+}
+
+// searchTwoStage retrieves an oversampled candidate set, then reranks it
+// and/or diversifies it with MMR before applying the offset and limit.
+func (s *searchImp) searchTwoStage(ctx context.Context, text string, vector []float32, opts model.SearchOptions) ([]model.Document, error) {
+	if opts.Rerank && s.reranker == nil {
+		return nil, fmt.Errorf("%w: no reranker is configured", ErrInvalidArgument)
+	}
+
+	want := opts.Offset + opts.Limit
+	oversample := mmrOversample
+	if opts.Rerank {
+		oversample = rerankOversample
+	}
 	query := model.VectorQuery{
 		QueryVector: vector,
-		Limit:       limit,
+		Limit:       min(want*oversample, model.MaxResultWindow),
+		Exact:       opts.Exact,
+		MinScore:    opts.MinScore,
+	}
+	if err := query.Validate(s.dim); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	docs, err := s.store.SimilaritySearch(ctx, query)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	if opts.Rerank {
+		if docs, err = s.reranker.Rerank(ctx, text, docs); err != nil {
+			return nil, fmt.Errorf("rerank: %w", err)
+		}
+	}
+	if opts.MMR {
+		docs = selectMMR(docs, want, opts.Lambda())
 	}
 
-	return s.store.SimilaritySearch(ctx, query)
+	if opts.Offset >= len(docs) {
+		return []model.Document{}, nil
+	}
+	return docs[opts.Offset:min(want, len(docs))], nil
 }
 
 func (s *searchImp) SearchByVector(ctx context.Context, query model.VectorQuery) ([]model.Document, error) {
+	ctx, span := tracing.Start(ctx, "service.SearchByVector")
+	defer span.End()
+
+	if len(query.QueryVector) != s.dim {
+		return nil, &DimensionError{Expected: s.dim, Got: len(query.QueryVector)}
+	}
 	if err := query.Validate(s.dim); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	docs, err := s.store.SimilaritySearch(ctx, query)
+	if err != nil {
+		return nil, storeError(err)
 	}
-	return s.store.SimilaritySearch(ctx, query)
+	searchResults.Observe(float64(len(docs)), "vector")
+	return docs, nil
 }
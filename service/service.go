@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"fmt"
 
+	"vector-database/analysis"
 	"vector-database/db/document"
 	"vector-database/model"
 )
@@ -10,14 +12,28 @@ import (
 type (
 	EncoderService interface {
 		Encode(ctx context.Context, text string) ([]float32, error)
+		EncodeBatch(ctx context.Context, texts []string) ([][]float32, error)
+	}
+
+	// CorpusObserver is implemented by encoders that learn statistics from
+	// the documents being indexed. Observe is called once a document is
+	// stored.
+	CorpusObserver interface {
+		Observe(ctx context.Context, text string) error
 	}
 
 	SearchService interface {
 		IndexDocument(ctx context.Context, input model.DocumentInput) (model.Document, error)
-		SearchByText(ctx context.Context, text string, limit int) ([]model.Document, error)
+		IndexDocuments(ctx context.Context, inputs []model.DocumentInput) ([]model.Document, error)
+		SearchByText(ctx context.Context, text string, opts model.SearchOptions) ([]model.Document, error)
+		GroupByText(ctx context.Context, query model.GroupQuery) (model.GroupedResults, error)
+		SearchByTerms(ctx context.Context, query model.TermQuery) ([]model.Document, error)
 		SearchByVector(ctx context.Context, query model.VectorQuery) ([]model.Document, error)
+		SimilarDocuments(ctx context.Context, query model.SimilarQuery) ([]model.Document, error)
 		InsertImage(ctx context.Context, input model.ImageInput) (model.ImageDocument, error)
 		SearchImages(ctx context.Context, query model.ImageQuery) ([]model.ImageDocument, error)
+		SimilarImages(ctx context.Context, query model.SimilarQuery) ([]model.ImageDocument, error)
+		TrainIndex(ctx context.Context) (model.IndexInfo, error)
 	}
 
 	AnalyzeService interface {
@@ -26,23 +42,40 @@ type (
 )
 
 type searchImp struct {
-	store   document.Store
-	encoder EncoderService
-	dim     int
+	store    document.Store
+	encoder  EncoderService
+	dim      int
+	batch    BatchOptions
+	reranker Reranker
 }
 
 type encoderImp struct {
 	Dimension int
+	analyzer  analysis.Analyzer
+}
+
+// TrainIndex trains the store's ANN index when the store maintains its own.
+func (s *searchImp) TrainIndex(ctx context.Context) (model.IndexInfo, error) {
+	trainer, ok := s.store.(document.Trainer)
+	if !ok {
+		return model.IndexInfo{}, fmt.Errorf("%w: the configured store does not support index training", ErrInvalidArgument)
+	}
+	info, err := trainer.TrainIndex(ctx)
+	return info, storeError(err)
 }
 
-func NewEncoder(dimension int) (EncoderService, error) {
-	return &encoderImp{Dimension: dimension}, nil
+func NewEncoder(dimension int, analyzer analysis.Analyzer) (EncoderService, error) {
+	return &encoderImp{Dimension: dimension, analyzer: analyzer}, nil
 }
 
-func NewSearch(store document.Store, encoder EncoderService, dimension int) (SearchService, error) {
+// NewSearch wires the search service. reranker may be nil, in which case
+// requests asking for reranking are rejected.
+func NewSearch(store document.Store, encoder EncoderService, dimension int, batch BatchOptions, reranker Reranker) (SearchService, error) {
 	return &searchImp{
-		store:   store,
-		encoder: encoder,
-		dim:     dimension,
+		store:    store,
+		encoder:  encoder,
+		dim:      dimension,
+		batch:    batch,
+		reranker: reranker,
 	}, nil
 }
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/model"
+	"vector-database/tracing"
+)
+
+// Rocchio weights for the source document, the positive examples and the
+// negative examples. The source dominates so examples steer rather than
+// replace it, and negatives weigh less than positives because a document
+// a user rejects says less about what they want than one they pick. The
+// README documents the resulting formula.
+const (
+	rocchioAlpha = 1.0
+	rocchioBeta  = 0.75
+	rocchioGamma = 0.25
+)
+
+// maxSimilarExamples caps the positive and the negative ids of a similar
+// search, each, so one request cannot load an unbounded number of
+// documents.
+const maxSimilarExamples = 100
+
+// SimilarDocuments finds documents close to an existing one. The query
+// vector is alpha*source + beta*mean(positive) - gamma*mean(negative), and
+// every referenced document is excluded from the results.
+func (s *searchImp) SimilarDocuments(ctx context.Context, query model.SimilarQuery) ([]model.Document, error) {
+	ctx, span := tracing.Start(ctx, "service.SimilarDocuments")
+	defer span.End()
+
+	opts := query.Options
+	if opts.Limit <= 0 {
+		return nil, fmt.Errorf("%w: limit must be positive", ErrInvalidArgument)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if opts.Cursor != nil || opts.Rerank {
+		return nil, fmt.Errorf("%w: similar searches support offset paging and mmr only", ErrInvalidArgument)
+	}
+
+	if len(query.Positive) > maxSimilarExamples || len(query.Negative) > maxSimilarExamples {
+		return nil, fmt.Errorf("%w: at most %d positive and %d negative ids are allowed", ErrInvalidArgument, maxSimilarExamples, maxSimilarExamples)
+	}
+
+	ids := append(append([]string{query.ID}, query.Positive...), query.Negative...)
+	docs, err := s.loadDocuments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	excluded := map[primitive.ObjectID]struct{}{}
+	for _, doc := range docs {
+		excluded[doc.ID] = struct{}{}
+	}
+	positive := embeddings(docs[1 : 1+len(query.Positive)])
+	negative := embeddings(docs[1+len(query.Positive):])
+	vector := rocchio(docs[0].Embedding, positive, negative, s.dim)
+
+	want := opts.Offset + opts.Limit
+	fetch := want + len(excluded)
+	if opts.MMR {
+		fetch = want*mmrOversample + len(excluded)
+	}
+	vq := model.VectorQuery{
+		QueryVector: vector,
+		Limit:       fetch,
+		Exact:       opts.Exact,
+		MinScore:    opts.MinScore,
+	}
+	if err := vq.Validate(s.dim); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	found, err := s.store.SimilaritySearch(ctx, vq)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	kept := found[:0]
+	for _, doc := range found {
+		if _, skip := excluded[doc.ID]; !skip {
+			kept = append(kept, doc)
+		}
+	}
+	if opts.MMR {
+		kept = selectMMR(kept, want, opts.Lambda())
+	}
+
+	page := []model.Document{}
+	if opts.Offset < len(kept) {
+		page = kept[opts.Offset:min(want, len(kept))]
+	}
+	searchResults.Observe(float64(len(page)), "similar")
+	return page, nil
+}
+
+// SimilarImages is SimilarDocuments shaped as image results.
+func (s *searchImp) SimilarImages(ctx context.Context, query model.SimilarQuery) ([]model.ImageDocument, error) {
+	docs, err := s.SimilarDocuments(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]model.ImageDocument, len(docs))
+	for i, doc := range docs {
+		results[i] = newImageDocument(doc)
+	}
+	return results, nil
+}
+
+// loadDocuments fetches the documents with ids in a single store call and
+// returns them in the order of ids, repeating documents whose id repeats.
+func (s *searchImp) loadDocuments(ctx context.Context, ids []string) ([]model.Document, error) {
+	objectIDs := make([]primitive.ObjectID, len(ids))
+	for i, id := range ids {
+		objectID, err := parseDocumentID(id)
+		if err != nil {
+			return nil, err
+		}
+		objectIDs[i] = objectID
+	}
+
+	found, err := s.store.GetDocuments(ctx, objectIDs)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	byID := make(map[primitive.ObjectID]model.Document, len(found))
+	for _, doc := range found {
+		byID[doc.ID] = doc
+	}
+
+	docs := make([]model.Document, len(ids))
+	for i, objectID := range objectIDs {
+		doc, ok := byID[objectID]
+		if !ok {
+			return nil, fmt.Errorf("%w: document %s", ErrNotFound, ids[i])
+		}
+		// A stored embedding of the wrong length is the store's fault, not
+		// the caller's, so it is an internal error rather than a
+		// DimensionError.
+		if len(doc.Embedding) != s.dim {
+			return nil, fmt.Errorf("document %s has a %d-dimensional embedding, expected %d", ids[i], len(doc.Embedding), s.dim)
+		}
+		docs[i] = doc
+	}
+	return docs, nil
+}
+
+// rocchio returns the normalised query vector
+// alpha*source + beta*mean(positive) - gamma*mean(negative).
+func rocchio(source []float32, positive, negative [][]float32, dim int) []float32 {
+	vector := make([]float32, dim)
+	addScaled(vector, source, rocchioAlpha)
+	addScaled(vector, meanVector(positive, dim), rocchioBeta)
+	addScaled(vector, meanVector(negative, dim), -rocchioGamma)
+	normalise(vector)
+	return vector
+}
+
+func embeddings(docs []model.Document) [][]float32 {
+	vectors := make([][]float32, len(docs))
+	for i, doc := range docs {
+		vectors[i] = doc.Embedding
+	}
+	return vectors
+}
+
+func meanVector(vectors [][]float32, dim int) []float32 {
+	mean := make([]float32, dim)
+	if len(vectors) == 0 {
+		return mean
+	}
+	for _, v := range vectors {
+		addScaled(mean, v, 1)
+	}
+	for i := range mean {
+		mean[i] /= float32(len(vectors))
+	}
+	return mean
+}
+
+func addScaled(dst, src []float32, factor float64) {
+	for i, v := range src {
+		dst[i] += float32(factor) * v
+	}
+}
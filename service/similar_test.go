@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/config"
+	"vector-database/db/document"
+	"vector-database/model"
+	"vector-database/tenant"
+)
+
+// newSimilarService is a search service over an empty two-dimensional
+// memory store.
+func newSimilarService(t *testing.T) (*searchImp, document.Store) {
+	t.Helper()
+	store, err := document.NewMemoryStore(config.MongoDB{EmbeddingDimension: 2}, config.Store{Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc, err := NewSearch(store, &lengthEncoder{}, 2, BatchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc.(*searchImp), store
+}
+
+func TestSimilarDocumentsStaysInTenant(t *testing.T) {
+	svc, store := newSimilarService(t)
+	acme := tenant.NewContext(context.Background(), "acme")
+	other := tenant.NewContext(context.Background(), "other")
+
+	source, err := store.InsertDocument(acme, model.DocumentInput{Content: "acme source"}, []float32{1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.InsertDocument(acme, model.DocumentInput{Content: "acme near"}, []float32{1, 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.InsertDocument(other, model.DocumentInput{Content: "other near"}, []float32{1, 0.05}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.SimilarDocuments(other, model.SimilarQuery{ID: source.ID.Hex(), Options: model.SearchOptions{Limit: 5}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("other tenant used acme's document as a source: %v", err)
+	}
+
+	docs, err := svc.SimilarDocuments(acme, model.SimilarQuery{ID: source.ID.Hex(), Options: model.SearchOptions{Limit: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(docs); len(got) != 1 || got[0] != "acme near" {
+		t.Errorf("acme's similar documents = %v, want [acme near]", got)
+	}
+}
+
+func TestRocchio(t *testing.T) {
+	cases := []struct {
+		name     string
+		positive [][]float32
+		negative [][]float32
+		want     []float32
+	}{
+		{"source only", nil, nil, []float32{1, 0}},
+		// 1*[1 0] + 0.75*mean([0 1], [0 1]) = [1 0.75]
+		{"positive", [][]float32{{0, 1}, {0, 1}}, nil, []float32{0.8, 0.6}},
+		// 1*[1 0] + 0.75*mean([0 2], [0 0]) = [1 0.75]
+		{"positive mean", [][]float32{{0, 2}, {0, 0}}, nil, []float32{0.8, 0.6}},
+		// 1*[1 0] + 0.75*[0 1] - 0.25*[1 0] = [0.75 0.75]
+		{"negative", [][]float32{{0, 1}}, [][]float32{{1, 0}}, []float32{math.Sqrt2 / 2, math.Sqrt2 / 2}},
+	}
+	for _, tc := range cases {
+		got := rocchio([]float32{1, 0}, tc.positive, tc.negative, 2)
+		for i := range got {
+			if math.Abs(float64(got[i]-tc.want[i])) > 1e-6 {
+				t.Errorf("%s: rocchio = %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
+
+// countingStore counts document loads.
+type countingStore struct {
+	document.Store
+	loads int
+}
+
+func (c *countingStore) GetDocuments(ctx context.Context, ids []primitive.ObjectID) ([]model.Document, error) {
+	c.loads++
+	return c.Store.GetDocuments(ctx, ids)
+}
+
+func TestSimilarDocumentsSteersWithExamples(t *testing.T) {
+	svc, store := newSimilarService(t)
+	counting := &countingStore{Store: store}
+	svc.store = counting
+	ctx := context.Background()
+
+	insert := func(content string, embedding []float32) string {
+		t.Helper()
+		doc, err := store.InsertDocument(ctx, model.DocumentInput{Content: content}, embedding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return doc.ID.Hex()
+	}
+	source := insert("source", []float32{1, 0})
+	up := insert("up", []float32{0, 1})
+	down := insert("down", []float32{1, -1})
+	insert("near up", []float32{0.8, 0.6})
+	insert("near down", []float32{0.8, -0.6})
+
+	docs, err := svc.SimilarDocuments(ctx, model.SimilarQuery{ID: source, Options: model.SearchOptions{Limit: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(docs); len(got) != 1 || got[0] == "source" {
+		t.Errorf("similar documents without examples = %v, want one other document", got)
+	}
+
+	counting.loads = 0
+	docs, err = svc.SimilarDocuments(ctx, model.SimilarQuery{ID: source, Positive: []string{up}, Negative: []string{down}, Options: model.SearchOptions{Limit: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(docs); len(got) != 2 || got[0] != "near up" {
+		t.Errorf("steered similar documents = %v, want near up first and every example left out", got)
+	}
+	if counting.loads != 1 {
+		t.Errorf("loaded the source and examples in %d calls, want one", counting.loads)
+	}
+}
+
+func TestSimilarDocumentsRejectsBadExamples(t *testing.T) {
+	svc, store := newSimilarService(t)
+	ctx := context.Background()
+	source, err := store.InsertDocument(ctx, model.DocumentInput{Content: "source"}, []float32{1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tooMany := make([]string, maxSimilarExamples+1)
+	for i := range tooMany {
+		tooMany[i] = source.ID.Hex()
+	}
+	cases := []struct {
+		name  string
+		query model.SimilarQuery
+		want  error
+	}{
+		{"missing source", model.SimilarQuery{ID: primitive.NewObjectID().Hex()}, ErrNotFound},
+		{"missing positive", model.SimilarQuery{ID: source.ID.Hex(), Positive: []string{primitive.NewObjectID().Hex()}}, ErrNotFound},
+		{"missing negative", model.SimilarQuery{ID: source.ID.Hex(), Negative: []string{primitive.NewObjectID().Hex()}}, ErrNotFound},
+		{"malformed id", model.SimilarQuery{ID: source.ID.Hex(), Positive: []string{"nope"}}, ErrInvalidArgument},
+		{"too many positive", model.SimilarQuery{ID: source.ID.Hex(), Positive: tooMany}, ErrInvalidArgument},
+		{"too many negative", model.SimilarQuery{ID: source.ID.Hex(), Negative: tooMany}, ErrInvalidArgument},
+	}
+	for _, tc := range cases {
+		tc.query.Options = model.SearchOptions{Limit: 5}
+		if _, err := svc.SimilarDocuments(ctx, tc.query); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"vector-database/analysis"
+)
+
+const (
+	defaultSIFWeight      = 1e-3
+	defaultPCSampleWords  = 10000
+	powerIterationRounds  = 50
+	maxWordVectorLineSize = 1 << 20
+)
+
+// StaticOptions configures the static word-vector encoder.
+type StaticOptions struct {
+	// Path points at a GloVe or fastText ".vec" text file.
+	Path string
+	// SIFWeight is the "a" in the a/(a+p(w)) smooth inverse frequency weight.
+	SIFWeight float64
+	// MaxWords caps how many of the (frequency sorted) words are loaded.
+	MaxWords int
+	// PCSampleWords is how many of the most frequent words estimate the
+	// common component removed from every sentence embedding.
+	PCSampleWords int
+}
+
+// staticEncoder produces SIF sentence embeddings from pre-trained word
+// vectors: a frequency-weighted average of the word vectors with the first
+// principal component removed.
+type staticEncoder struct {
+	dimension int
+	analyzer  analysis.Analyzer
+	sifWeight float64
+	vectors   map[string][]float32
+	weights   map[string]float32
+	component []float32
+}
+
+// NewStaticEncoder loads word vectors from disk. The file's vector size must
+// match dimension. Word vector files list words by descending corpus
+// frequency, so word probabilities are estimated from rank with Zipf's law.
+// The analyzer must not stem, since stems are not in the vocabulary.
+func NewStaticEncoder(dimension int, analyzer analysis.Analyzer, opts StaticOptions) (EncoderService, error) {
+	if dimension <= 0 {
+		return nil, errors.New("encoder dimension must be positive")
+	}
+	if opts.SIFWeight <= 0 {
+		opts.SIFWeight = defaultSIFWeight
+	}
+	if opts.PCSampleWords <= 0 {
+		opts.PCSampleWords = defaultPCSampleWords
+	}
+
+	words, vectors, err := loadWordVectors(opts.Path, dimension, opts.MaxWords)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("word vectors %s contain no words", opts.Path)
+	}
+
+	enc := &staticEncoder{
+		dimension: dimension,
+		analyzer:  analyzer,
+		sifWeight: opts.SIFWeight,
+		vectors:   make(map[string][]float32, len(words)),
+		weights:   make(map[string]float32, len(words)),
+	}
+
+	var harmonic float64
+	for rank := 1; rank <= len(words); rank++ {
+		harmonic += 1 / float64(rank)
+	}
+
+	// Files may list several casings of a word. The word's probability
+	// comes from its most frequent form, while its vector comes from the
+	// lowercase entry when there is one, whatever its position.
+	var (
+		distinct  = make([]string, 0, len(words))
+		lowercase = make(map[string]bool, len(words))
+	)
+	for i, word := range words {
+		key := strings.ToLower(word)
+		if _, seen := enc.vectors[key]; !seen {
+			probability := 1 / (float64(i+1) * harmonic)
+			enc.weights[key] = float32(opts.SIFWeight / (opts.SIFWeight + probability))
+			distinct = append(distinct, key)
+		} else if lowercase[key] || word != key {
+			continue
+		}
+		enc.vectors[key] = vectors[i]
+		lowercase[key] = word == key
+	}
+
+	sample := distinct[:min(opts.PCSampleWords, len(distinct))]
+	weighted := make([][]float32, len(sample))
+	for i, word := range sample {
+		weighted[i] = scaled(enc.vectors[word], enc.weights[word])
+	}
+	enc.component = principalComponent(weighted, dimension)
+
+	return enc, nil
+}
+
+func (s *staticEncoder) Encode(_ context.Context, text string) ([]float32, error) {
+	vector := make([]float32, s.dimension)
+
+	var known int
+	for _, token := range s.analyzer.Analyze(text) {
+		wordVector, ok := s.vectors[token]
+		if !ok {
+			continue
+		}
+		weight := s.weights[token]
+		for i, v := range wordVector {
+			vector[i] += weight * v
+		}
+		known++
+	}
+	if known == 0 {
+		return vector, nil
+	}
+
+	for i := range vector {
+		vector[i] /= float32(known)
+	}
+
+	projection := dot(vector, s.component)
+	for i, c := range s.component {
+		vector[i] -= projection * c
+	}
+
+	normalise(vector)
+	return vector, nil
+}
+
+func (s *staticEncoder) EncodeBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return encodeEach(ctx, s, texts)
+}
+
+// loadWordVectors reads the ".vec" text format: an optional "count dim"
+// header followed by one "word v1 v2 ... vN" line per word.
+func loadWordVectors(path string, dimension, maxWords int) ([]string, [][]float32, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open word vectors: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxWordVectorLineSize)
+
+	var (
+		words   []string
+		vectors [][]float32
+		line    int
+	)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if line == 1 && len(fields) == 2 {
+			if dim, err := strconv.Atoi(fields[1]); err == nil {
+				if dim != dimension {
+					return nil, nil, fmt.Errorf("word vectors have dimension %d, expected %d", dim, dimension)
+				}
+				continue
+			}
+		}
+		if len(fields)-1 != dimension {
+			return nil, nil, fmt.Errorf("word vectors line %d has dimension %d, expected %d", line, len(fields)-1, dimension)
+		}
+
+		vector := make([]float32, dimension)
+		for i, raw := range fields[1:] {
+			value, err := strconv.ParseFloat(raw, 32)
+			if err != nil {
+				return nil, nil, fmt.Errorf("word vectors line %d: parse component %d: %w", line, i, err)
+			}
+			vector[i] = float32(value)
+		}
+		words = append(words, fields[0])
+		vectors = append(vectors, vector)
+
+		if maxWords > 0 && len(words) >= maxWords {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("read word vectors: %w", err)
+	}
+	return words, vectors, nil
+}
+
+// principalComponent finds the dominant direction of the (uncentered)
+// sample with power iteration, avoiding a dimension x dimension matrix.
+func principalComponent(sample [][]float32, dimension int) []float32 {
+	component := make([]float32, dimension)
+	for i := range component {
+		component[i] = 1 / float32(math.Sqrt(float64(dimension)))
+	}
+
+	next := make([]float64, dimension)
+	for round := 0; round < powerIterationRounds; round++ {
+		for i := range next {
+			next[i] = 0
+		}
+		for _, x := range sample {
+			projection := float64(dot(x, component))
+			for i, v := range x {
+				next[i] += projection * float64(v)
+			}
+		}
+
+		var norm float64
+		for _, v := range next {
+			norm += v * v
+		}
+		if norm == 0 {
+			return make([]float32, dimension)
+		}
+		norm = math.Sqrt(norm)
+		for i, v := range next {
+			component[i] = float32(v / norm)
+		}
+	}
+	return component
+}
+
+func scaled(vector []float32, factor float32) []float32 {
+	out := make([]float32, len(vector))
+	for i, v := range vector {
+		out[i] = v * factor
+	}
+	return out
+}
+
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vector-database/analysis"
+	"vector-database/config"
+)
+
+func writeVectors(t *testing.T, lines string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vectors.vec")
+	if err := os.WriteFile(path, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStaticEncoderPrefersLowercaseEntries(t *testing.T) {
+	path := writeVectors(t, "4 2\nApple 0 1\nthe 1 1\napple 1 0\nApple 5 5\n")
+	analyzer, _ := analysis.New(config.Analyzer{})
+	enc, err := NewStaticEncoder(2, analyzer, StaticOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	static := enc.(*staticEncoder)
+
+	if got := static.vectors["apple"]; got[0] != 1 || got[1] != 0 {
+		t.Errorf("apple vector = %v, want the lowercase entry [1 0]", got)
+	}
+	if static.weights["apple"] >= static.weights["the"] {
+		t.Errorf("apple weight %v should use the rank of Apple, ahead of the", static.weights["apple"])
+	}
+	if len(static.vectors) != 2 {
+		t.Errorf("vocabulary = %v, want apple and the", static.vectors)
+	}
+}
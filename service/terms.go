@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"vector-database/model"
+	"vector-database/tracing"
+)
+
+// SearchByTerms searches with the weighted sum of several text and vector
+// terms. Every term is normalised before weighting so that the weights alone
+// decide its influence; negative weights steer results away from a term.
+func (s *searchImp) SearchByTerms(ctx context.Context, query model.TermQuery) ([]model.Document, error) {
+	ctx, span := tracing.Start(ctx, "service.SearchByTerms")
+	defer span.End()
+
+	opts := query.Options
+	if opts.Limit <= 0 {
+		return nil, fmt.Errorf("%w: limit must be positive", ErrInvalidArgument)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArgument, err)
+	}
+
+	text := query.RerankText()
+	if opts.Rerank && text == "" {
+		return nil, fmt.Errorf("%w: rerank requires a positively weighted text term", ErrInvalidArgument)
+	}
+
+	vector, err := s.combineTerms(ctx, query.Terms)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.searchVector(ctx, text, vector, opts)
+	if err != nil {
+		return nil, err
+	}
+	searchResults.Observe(float64(len(docs)), "terms")
+	return docs, nil
+}
+
+func (s *searchImp) combineTerms(ctx context.Context, terms []model.WeightedTerm) ([]float32, error) {
+	var texts []string
+	for _, term := range terms {
+		if term.HasText() {
+			texts = append(texts, term.Text)
+		}
+	}
+
+	var encoded [][]float32
+	if len(texts) > 0 {
+		var err error
+		if encoded, err = encodeAll(ctx, s.encoder, texts, s.batch); err != nil {
+			return nil, fmt.Errorf("encode query: %w", err)
+		}
+	}
+
+	combined := make([]float32, s.dim)
+	for i, term := range terms {
+		vector := term.Vector
+		if term.HasText() {
+			vector, encoded = encoded[0], encoded[1:]
+		} else if len(vector) != s.dim {
+			return nil, fmt.Errorf("term %d: %w", i, &DimensionError{Expected: s.dim, Got: len(vector)})
+		}
+
+		unit := append([]float32(nil), vector...)
+		normalise(unit)
+		addScaled(combined, unit, term.Weight)
+	}
+
+	if dot(combined, combined) == 0 {
+		return nil, fmt.Errorf("%w: query terms cancel out", ErrInvalidArgument)
+	}
+	normalise(combined)
+	return combined, nil
+}
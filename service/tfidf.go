@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+
+	"vector-database/analysis"
+	"vector-database/db/corpus"
+	"vector-database/tenant"
+)
+
+// tfidfEncoder hashes unigrams and word bigrams into a signed feature vector
+// weighted by sublinear term frequency and corpus inverse document frequency.
+// Each tenant has its own corpus, so one tenant's documents never change
+// another's embeddings.
+type tfidfEncoder struct {
+	dimension int
+	analyzer  analysis.Analyzer
+	store     corpus.Store
+
+	mu      sync.RWMutex
+	corpora map[string]*corpusStats
+}
+
+// corpusStats are the document frequencies of one tenant's documents.
+type corpusStats struct {
+	documents int64
+	frequency map[string]int64
+}
+
+// NewTFIDFEncoder loads the persisted corpus statistics and returns an
+// encoder that keeps them up to date as documents are indexed. A nil store
+// keeps the statistics in memory only.
+func NewTFIDFEncoder(ctx context.Context, dimension int, analyzer analysis.Analyzer, store corpus.Store) (EncoderService, error) {
+	if dimension <= 0 {
+		return nil, errors.New("encoder dimension must be positive")
+	}
+
+	enc := &tfidfEncoder{
+		dimension: dimension,
+		analyzer:  analyzer,
+		store:     store,
+		corpora:   map[string]*corpusStats{},
+	}
+	if store == nil {
+		return enc, nil
+	}
+
+	loaded, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load corpus stats: %w", err)
+	}
+	for owner, stats := range loaded {
+		enc.corpora[owner] = &corpusStats{documents: stats.Documents, frequency: stats.DocumentFrequency}
+	}
+	return enc, nil
+}
+
+// Encode weighs text by the corpus of the context's tenant.
+func (t *tfidfEncoder) Encode(ctx context.Context, text string) ([]float32, error) {
+	vector := make([]float32, t.dimension)
+	features := t.features(text)
+	if len(features) == 0 {
+		return vector, nil
+	}
+
+	counts := make(map[string]int, len(features))
+	for _, feature := range features {
+		counts[feature]++
+	}
+
+	t.mu.RLock()
+	stats := t.corpora[tenant.FromContext(ctx)]
+	for feature, count := range counts {
+		weight := (1 + math.Log(float64(count))) * stats.idf(feature)
+
+		hasher := fnv.New64a()
+		_, _ = hasher.Write([]byte(feature))
+		sum := hasher.Sum64()
+		idx := (sum >> 1) % uint64(t.dimension)
+		if sum&1 == 1 {
+			weight = -weight
+		}
+		vector[idx] += float32(weight)
+	}
+	t.mu.RUnlock()
+
+	normalise(vector)
+	return vector, nil
+}
+
+func (t *tfidfEncoder) EncodeBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return encodeEach(ctx, t, texts)
+}
+
+// Observe records the distinct features of a document indexed for the
+// context's tenant.
+func (t *tfidfEncoder) Observe(ctx context.Context, text string) error {
+	distinct := t.distinctFeatures(text)
+	if t.store != nil {
+		if err := t.store.AddDocument(ctx, distinct); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	stats := t.corpus(ctx)
+	stats.documents++
+	for _, feature := range distinct {
+		stats.frequency[feature]++
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+// corpus returns the statistics of the context's tenant, creating them on
+// first use. Callers must hold the write lock.
+func (t *tfidfEncoder) corpus(ctx context.Context) *corpusStats {
+	owner := tenant.FromContext(ctx)
+	stats, ok := t.corpora[owner]
+	if !ok {
+		stats = &corpusStats{frequency: map[string]int64{}}
+		t.corpora[owner] = stats
+	}
+	return stats
+}
+
+func (t *tfidfEncoder) distinctFeatures(text string) []string {
+	features := t.features(text)
+	seen := make(map[string]struct{}, len(features))
+	distinct := make([]string, 0, len(features))
+	for _, feature := range features {
+		if _, ok := seen[feature]; ok {
+			continue
+		}
+		seen[feature] = struct{}{}
+		distinct = append(distinct, feature)
+	}
+	return distinct
+}
+
+// idf uses the smoothed form so unseen terms still carry weight. A nil
+// corpus, of a tenant without documents, weighs every term 1. Callers must
+// hold the encoder's read lock.
+func (c *corpusStats) idf(feature string) float64 {
+	if c == nil {
+		return 1
+	}
+	return math.Log(float64(1+c.documents)/float64(1+c.frequency[feature])) + 1
+}
+
+// features returns the analyzed unigrams followed by adjacent word bigrams.
+func (t *tfidfEncoder) features(text string) []string {
+	tokens := t.analyzer.Analyze(text)
+	if len(tokens) < 2 {
+		return tokens
+	}
+	features := make([]string, 0, 2*len(tokens)-1)
+	features = append(features, tokens...)
+	for i := 1; i < len(tokens); i++ {
+		features = append(features, tokens[i-1]+" "+tokens[i])
+	}
+	return features
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"vector-database/analysis"
+	"vector-database/config"
+	"vector-database/db/document"
+	"vector-database/model"
+	"vector-database/tenant"
+)
+
+func newTestTFIDF(t *testing.T) *tfidfEncoder {
+	t.Helper()
+	analyzer, err := analysis.New(config.Analyzer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := NewTFIDFEncoder(context.Background(), 64, analyzer, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc.(*tfidfEncoder)
+}
+
+func TestTFIDFIDF(t *testing.T) {
+	ctx := context.Background()
+	enc := newTestTFIDF(t)
+	for _, text := range []string{"red apple", "green apple", "red car"} {
+		if err := enc.Observe(ctx, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := map[string]float64{
+		"apple":     math.Log(4.0/3.0) + 1,
+		"car":       math.Log(4.0/2.0) + 1,
+		"red apple": math.Log(4.0/2.0) + 1,
+		"unseen":    math.Log(4.0/1.0) + 1,
+	}
+	for feature, want := range cases {
+		if got := enc.corpora[tenant.Default].idf(feature); math.Abs(got-want) > 1e-12 {
+			t.Errorf("idf(%q) = %v, want %v", feature, got, want)
+		}
+	}
+}
+
+func TestTFIDFEncodeWeighsRareTermsHigher(t *testing.T) {
+	ctx := context.Background()
+	enc := newTestTFIDF(t)
+	for _, text := range []string{"common rare", "common", "common"} {
+		_ = enc.Observe(ctx, text)
+	}
+
+	vector, err := enc.Encode(ctx, "common rare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v * v)
+	}
+	if math.Abs(norm-1) > 1e-5 {
+		t.Errorf("norm = %v, want 1", norm)
+	}
+
+	weight := func(feature string) float32 {
+		single, _ := enc.Encode(ctx, feature)
+		for i, v := range single {
+			if v != 0 {
+				return float32(math.Abs(float64(vector[i])))
+			}
+		}
+		return 0
+	}
+	if weight("rare") <= weight("common") {
+		t.Errorf("rare term weight %v is not above common term weight %v", weight("rare"), weight("common"))
+	}
+}
+
+// failingStore rejects every write.
+type failingStore struct {
+	document.Store
+}
+
+func (failingStore) InsertDocument(context.Context, model.DocumentInput, []float32) (model.Document, error) {
+	return model.Document{}, errors.New("write failed")
+}
+
+func (failingStore) InsertDocuments(context.Context, []model.DocumentInput, [][]float32) ([]model.Document, error) {
+	return nil, errors.New("write failed")
+}
+
+func (failingStore) GetDocuments(context.Context, []primitive.ObjectID) ([]model.Document, error) {
+	return nil, nil
+}
+
+func TestFailedWritesLeaveCorpusUntouched(t *testing.T) {
+	ctx := context.Background()
+	enc := newTestTFIDF(t)
+	svc, err := NewSearch(failingStore{}, enc, 64, BatchOptions{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.IndexDocument(ctx, model.DocumentInput{Content: "red apple"}); err == nil {
+		t.Fatal("IndexDocument succeeded, want an error")
+	}
+	if _, err := svc.IndexDocuments(ctx, []model.DocumentInput{{Content: "red"}, {Content: "apple"}}); err == nil {
+		t.Fatal("IndexDocuments succeeded, want an error")
+	}
+	if len(enc.corpora) != 0 {
+		t.Errorf("corpus changed after failed writes: %v", enc.corpora)
+	}
+}
+
+func TestTFIDFKeepsTenantCorporaApart(t *testing.T) {
+	enc := newTestTFIDF(t)
+	acme := tenant.NewContext(context.Background(), "acme")
+	other := tenant.NewContext(context.Background(), "other")
+
+	before, err := enc.Encode(other, "common rare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"common rare", "common", "common"} {
+		if err := enc.Observe(acme, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	after, err := enc.Encode(other, "common rare")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		t.Error("indexing for acme changed the embeddings of another tenant")
+	}
+	if _, ok := enc.corpora["other"]; ok {
+		t.Error("encoding created a corpus for a tenant without documents")
+	}
+}
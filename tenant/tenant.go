@@ -0,0 +1,24 @@
+// Package tenant carries the tenant a request acts for. Stores scope every
+// read and write to it.
+package tenant
+
+import "context"
+
+// Default owns documents written without an authenticated tenant, so a
+// deployment without auth behaves as a single tenant.
+const Default = "default"
+
+type tenantKey struct{}
+
+// NewContext returns a copy of ctx acting for tenant id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant of ctx, or Default when none is set.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+		return id
+	}
+	return Default
+}
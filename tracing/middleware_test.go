@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	callerTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+	callerSpan  = "00f067aa0ba902b7"
+)
+
+func TestMiddlewareParsesTraceparent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(recorder),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	cases := []struct {
+		name        string
+		traceparent string
+		continues   bool
+		recorded    bool
+	}{
+		{"sampled", "00-" + callerTrace + "-" + callerSpan + "-01", true, true},
+		{"not sampled", "00-" + callerTrace + "-" + callerSpan + "-00", true, false},
+		{"future version with extra field", "01-" + callerTrace + "-" + callerSpan + "-01-extra", true, true},
+		{"missing", "", false, true},
+		{"zero trace id", "00-00000000000000000000000000000000-" + callerSpan + "-01", false, true},
+		{"zero span id", "00-" + callerTrace + "-0000000000000000-01", false, true},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + callerSpan + "-01", false, true},
+		{"short trace id", "00-4bf92f35-" + callerSpan + "-01", false, true},
+		{"invalid version", "ff-" + callerTrace + "-" + callerSpan + "-01", false, true},
+		{"version 00 with extra field", "00-" + callerTrace + "-" + callerSpan + "-01-extra", false, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var sc trace.SpanContext
+			handler := Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				sc = trace.SpanContextFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			before := len(recorder.Ended())
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !sc.IsValid() {
+				t.Fatal("handler ran without a span")
+			}
+			if continues := sc.TraceID().String() == callerTrace; continues != tc.continues {
+				t.Errorf("trace id %s, continues caller = %v, want %v", sc.TraceID(), continues, tc.continues)
+			}
+			if sc.SpanID().String() == callerSpan {
+				t.Error("server span reused the caller's span id")
+			}
+			ended := recorder.Ended()[before:]
+			if recorded := len(ended) == 1; recorded != tc.recorded {
+				t.Fatalf("recorded %d spans, want recorded = %v", len(ended), tc.recorded)
+			}
+			if tc.recorded && tc.continues && ended[0].Parent().SpanID().String() != callerSpan {
+				t.Errorf("parent span = %s, want %s", ended[0].Parent().SpanID(), callerSpan)
+			}
+		})
+	}
+}
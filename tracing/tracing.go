@@ -0,0 +1,104 @@
+// Package tracing configures OpenTelemetry to record request spans,
+// propagate W3C trace context and export spans to stdout or an OTLP/HTTP
+// collector.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"vector-database/config"
+)
+
+const (
+	defaultServiceName = "vector-database"
+	instrumentation    = "vector-database"
+)
+
+var provider *sdktrace.TracerProvider
+
+// Init installs the global tracer provider and the W3C trace context
+// propagator. It does nothing when tracing is disabled, in which case Start
+// returns spans that record nothing.
+func Init(cfg config.Tracing) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return fmt.Errorf("create %s exporter: %w", cfg.Exporter, err)
+	}
+	service := cfg.ServiceName
+	if service == "" {
+		service = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(service)))
+	if err != nil {
+		return fmt.Errorf("build trace resource: %w", err)
+	}
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return nil
+}
+
+// newSampler follows the caller's sampling decision and records
+// cfg.SampleRatio of new traces, or every new trace when it is unset.
+func newSampler(cfg config.Tracing) sdktrace.Sampler {
+	ratio := 1.0
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
+}
+
+// newExporter posts OTLP/HTTP protobuf to <endpoint>/v1/traces, or writes
+// one JSON line per span to stdout.
+func newExporter(cfg config.Tracing) (sdktrace.SpanExporter, error) {
+	if cfg.Exporter == config.TracingOTLP {
+		return otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(strings.TrimSuffix(cfg.Endpoint, "/")+"/v1/traces"),
+			otlptracehttp.WithHeaders(cfg.Headers),
+		)
+	}
+	return stdouttrace.New()
+}
+
+// Shutdown exports the spans still queued and stops tracing.
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// Start begins a span as a child of the span in ctx, or of a new trace, and
+// returns a context carrying it.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, opts...)
+}
+
+// RecordError marks the span as failed. A nil error is ignored.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
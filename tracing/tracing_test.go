@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"vector-database/config"
+)
+
+func TestNewSamplerRatio(t *testing.T) {
+	zero, one := 0.0, 1.0
+	traceID, _ := trace.TraceIDFromHex(callerTrace)
+	spanID, _ := trace.SpanIDFromHex(callerSpan)
+	sampledParent := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+
+	cases := []struct {
+		name   string
+		ratio  *float64
+		root   bool
+		parent bool
+	}{
+		{"unset records every trace", nil, true, true},
+		{"zero records only sampled callers", &zero, false, true},
+		{"one", &one, true, true},
+	}
+	for _, tc := range cases {
+		sampler := newSampler(config.Tracing{SampleRatio: tc.ratio})
+		root := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: traceID, Name: "root"})
+		if sampled := root.Decision == sdktrace.RecordAndSample; sampled != tc.root {
+			t.Errorf("%s: new trace sampled = %v, want %v", tc.name, sampled, tc.root)
+		}
+		child := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: sampledParent, TraceID: traceID, Name: "child"})
+		if sampled := child.Decision == sdktrace.RecordAndSample; sampled != tc.parent {
+			t.Errorf("%s: sampled caller's trace sampled = %v, want %v", tc.name, sampled, tc.parent)
+		}
+	}
+}